@@ -0,0 +1,236 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/UnderTreeTech/waterdrop/pkg/registry"
+	"github.com/UnderTreeTech/waterdrop/pkg/stats"
+	// health probes are always mounted on the stats server
+	_ "github.com/UnderTreeTech/waterdrop/pkg/stats/health"
+	"github.com/UnderTreeTech/waterdrop/pkg/utils/xdefer"
+	"github.com/UnderTreeTech/waterdrop/pkg/utils/xnet"
+)
+
+// registerTimeout timeout of registering a service
+const registerTimeout = 10 * time.Second
+
+// App application orchestrates servers, registry and cleanups.
+// Servers are started in the order of rpc, http and stats server, and registered after all of
+// them start. On shutdown, services are deregistered, then servers are stopped and cleanups are
+// run in reverse order.
+type App struct {
+	opts     *options
+	ctx      context.Context
+	cancel   context.CancelFunc
+	defers   *xdefer.Defers
+	services []*registry.ServiceInfo
+	err      error
+}
+
+// New returns an application
+func New(opts ...Option) *App {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &App{
+		opts:   o,
+		ctx:    ctx,
+		cancel: cancel,
+		defers: xdefer.New(),
+	}
+}
+
+// Defer add cleanup funcs run on shutdown after servers stop, in reverse order,
+// e.g. closing tracer, databases and flushing logs
+func (a *App) Defer(fns ...func() error) {
+	a.defers.Add(fns...)
+}
+
+// Services returns services registered to registry
+func (a *App) Services() []*registry.ServiceInfo {
+	return a.services
+}
+
+// Run starts servers, registers them to registry and blocks until receiving
+// shutdown signals or Stop is called, then shuts down the application
+func (a *App) Run() error {
+	if a.opts.registry != nil && a.opts.name == "" {
+		return errors.New("app: name is required to register services")
+	}
+
+	// signals received during starting trigger shutdown once started.
+	// Notify relays all the signals if none given, so empty signals never trigger shutdown
+	ch := make(chan os.Signal, 1)
+	if len(a.opts.signals) > 0 {
+		signal.Notify(ch, a.opts.signals...)
+		defer signal.Stop(ch)
+	}
+
+	if err := a.runHooks(a.ctx, a.opts.beforeStart); err != nil {
+		a.defers.Close()
+		return err
+	}
+
+	if err := a.start(); err != nil {
+		a.shutdown()
+		return err
+	}
+
+	if err := a.runHooks(a.ctx, a.opts.afterStart); err != nil {
+		a.shutdown()
+		return err
+	}
+
+	select {
+	case sig := <-ch:
+		log.Printf("waterdrop: receive signal %s, shutting down", sig)
+	case <-a.ctx.Done():
+		log.Printf("waterdrop: app stopped, shutting down")
+	}
+
+	return a.shutdown()
+}
+
+// Stop triggers shutdown of a running application
+func (a *App) Stop() {
+	a.cancel()
+}
+
+// start starts servers and registers them
+func (a *App) start() error {
+	if srv := a.opts.rpcServer; srv != nil {
+		addr := srv.Start()
+		a.cleanup("rpc server", srv.Stop)
+		a.services = append(a.services, a.service("grpc", "grpc", addr))
+	}
+
+	if srv := a.opts.httpServer; srv != nil {
+		addr := srv.Start()
+		a.cleanup("http server", srv.Stop)
+		scheme := "http"
+		if srv.TLS() {
+			scheme = "https"
+		}
+		a.services = append(a.services, a.service("http", scheme, addr))
+	}
+
+	if a.opts.stats {
+		si, err := stats.StartStatsWithConfig(a.opts.name, a.opts.statsConfig)
+		if err != nil {
+			return fmt.Errorf("app: start stats server fail, %w", err)
+		}
+		a.cleanup("stats server", stats.StopStats)
+		si.Metadata = a.opts.metadata
+		a.services = append(a.services, si)
+	}
+
+	if a.opts.registry == nil {
+		return nil
+	}
+
+	for _, si := range a.services {
+		ctx, cancel := context.WithTimeout(a.ctx, registerTimeout)
+		err := a.opts.registry.Register(ctx, si)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("app: register service %s fail, %w", si.Addr, err)
+		}
+
+		si := si
+		a.cleanup("service "+si.Addr, func(ctx context.Context) error {
+			return a.opts.registry.DeRegister(ctx, si)
+		})
+	}
+
+	return nil
+}
+
+// shutdown runs before stop hooks, cleanups and after stop hooks in order,
+// it returns the first error
+func (a *App) shutdown() error {
+	a.cancel()
+	a.setErr(a.runHooks(context.Background(), a.opts.beforeStop))
+	a.defers.Close()
+	a.setErr(a.runHooks(context.Background(), a.opts.afterStop))
+	return a.err
+}
+
+// cleanup add a cleanup which runs fn within stop timeout
+func (a *App) cleanup(name string, fn func(ctx context.Context) error) {
+	a.defers.Add(func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), a.opts.stopTimeout)
+		defer cancel()
+
+		if err := fn(ctx); err != nil {
+			log.Printf("waterdrop: stop %s fail, err msg %s", name, err.Error())
+			a.setErr(err)
+			return err
+		}
+		return nil
+	})
+}
+
+// runHooks runs hooks in order within stop timeout each, it stops at the first error
+func (a *App) runHooks(ctx context.Context, hooks []Hook) error {
+	for _, hook := range hooks {
+		hctx, cancel := context.WithTimeout(ctx, a.opts.stopTimeout)
+		err := hook(hctx)
+		cancel()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setErr records the first error
+func (a *App) setErr(err error) {
+	if a.err == nil {
+		a.err = err
+	}
+}
+
+// service returns the service info of server listening on addr, unspecified
+// host is replaced with internal ip
+func (a *App) service(kind string, scheme string, addr net.Addr) *registry.ServiceInfo {
+	host, port, _ := net.SplitHostPort(addr.String())
+	if ip := net.ParseIP(host); ip == nil || ip.IsUnspecified() {
+		host = xnet.InternalIP()
+	}
+
+	return &registry.ServiceInfo{
+		Name:     a.opts.name,
+		Scheme:   kind,
+		Addr:     fmt.Sprintf("%s://%s", scheme, net.JoinHostPort(host, port)),
+		Metadata: a.opts.metadata,
+		Version:  a.opts.version,
+	}
+}
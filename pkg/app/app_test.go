@@ -0,0 +1,238 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package app
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/UnderTreeTech/waterdrop/pkg/log"
+	"github.com/UnderTreeTech/waterdrop/pkg/registry"
+	httpconfig "github.com/UnderTreeTech/waterdrop/pkg/server/http/config"
+	httpserver "github.com/UnderTreeTech/waterdrop/pkg/server/http/server"
+	rpcconfig "github.com/UnderTreeTech/waterdrop/pkg/server/rpc/config"
+	rpcserver "github.com/UnderTreeTech/waterdrop/pkg/server/rpc/server"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	defer log.New(nil).Sync()
+	os.Exit(m.Run())
+}
+
+// recorder records lifecycle events in order
+type recorder struct {
+	mutex  sync.Mutex
+	events []string
+}
+
+func (r *recorder) add(event string) {
+	r.mutex.Lock()
+	r.events = append(r.events, event)
+	r.mutex.Unlock()
+}
+
+func (r *recorder) hook(event string) Hook {
+	return func(ctx context.Context) error {
+		r.add(event)
+		return nil
+	}
+}
+
+// mockRegistry records registered services
+type mockRegistry struct {
+	*recorder
+	fail bool
+}
+
+func (m *mockRegistry) Register(ctx context.Context, info *registry.ServiceInfo) error {
+	if m.fail {
+		return errors.New("register fail")
+	}
+	m.add("register " + info.Scheme)
+	return nil
+}
+
+func (m *mockRegistry) DeRegister(ctx context.Context, info *registry.ServiceInfo) error {
+	m.add("deregister " + info.Scheme)
+	return nil
+}
+
+func (m *mockRegistry) List(ctx context.Context, name string, scheme string) ([]*registry.ServiceInfo, error) {
+	return nil, nil
+}
+
+func (m *mockRegistry) Close() {}
+
+// newServers returns http and rpc servers listening on random ports
+func newServers() (*httpserver.Server, *rpcserver.Server) {
+	hcfg := httpconfig.DefaultServerConfig()
+	hcfg.Addr = "127.0.0.1:0"
+	rcfg := rpcconfig.DefaultServerConfig()
+	rcfg.Addr = "127.0.0.1:0"
+	return httpserver.New(hcfg), rpcserver.New(rcfg)
+}
+
+func TestRunSignal(t *testing.T) {
+	rec := &recorder{}
+	app := New(
+		DisableStats(),
+		Signals(syscall.SIGUSR1),
+		AfterStart(func(ctx context.Context) error {
+			return syscall.Kill(os.Getpid(), syscall.SIGUSR1)
+		}),
+		AfterStop(rec.hook("after stop")),
+	)
+
+	done := make(chan error)
+	go func() {
+		done <- app.Run()
+	}()
+
+	select {
+	case err := <-done:
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"after stop"}, rec.events)
+	case <-time.After(5 * time.Second):
+		t.Fatal("app is not stopped by signal")
+	}
+}
+
+func TestRunNoSignal(t *testing.T) {
+	app := New(
+		DisableStats(),
+		Signals(),
+		AfterStart(func(ctx context.Context) error {
+			return syscall.Kill(os.Getpid(), syscall.SIGWINCH)
+		}),
+	)
+
+	done := make(chan error)
+	go func() {
+		done <- app.Run()
+	}()
+
+	// no signal triggers shutdown if signals is empty
+	select {
+	case <-done:
+		t.Fatal("app is stopped by signal")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	app.Stop()
+	select {
+	case err := <-done:
+		assert.Nil(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("app is not stopped")
+	}
+}
+
+func TestRunFail(t *testing.T) {
+	rec := &recorder{}
+	httpSrv, _ := newServers()
+
+	// register failure stops started servers
+	app := New(
+		Name("waterdrop"),
+		DisableStats(),
+		Registry(&mockRegistry{recorder: rec, fail: true}),
+		HTTPServer(httpSrv),
+		AfterStart(rec.hook("after start")),
+		AfterStop(rec.hook("after stop")),
+	)
+	assert.NotNil(t, app.Run())
+	assert.Equal(t, []string{"after stop"}, rec.events)
+
+	// before start hooks failure aborts
+	rec = &recorder{}
+	app = New(
+		BeforeStart(func(ctx context.Context) error {
+			return errors.New("before start fail")
+		}),
+		AfterStart(rec.hook("after start")),
+	)
+	app.Defer(func() error {
+		rec.add("cleanup")
+		return nil
+	})
+	assert.EqualError(t, app.Run(), "before start fail")
+	assert.Equal(t, []string{"cleanup"}, rec.events)
+
+	// name is required to register services
+	assert.NotNil(t, New(Registry(&mockRegistry{recorder: rec})).Run())
+}
+
+func TestRun(t *testing.T) {
+	rec := &recorder{}
+	httpSrv, rpcSrv := newServers()
+	httpSrv.GET("/ping", func(c *gin.Context) {})
+
+	var app *App
+	app = New(
+		Name("waterdrop"),
+		Registry(&mockRegistry{recorder: rec}),
+		HTTPServer(httpSrv),
+		RPCServer(rpcSrv),
+		BeforeStart(rec.hook("before start")),
+		AfterStart(rec.hook("after start"), func(ctx context.Context) error {
+			go app.Stop()
+			return nil
+		}),
+		BeforeStop(rec.hook("before stop")),
+		AfterStop(rec.hook("after stop")),
+	)
+	app.Defer(func() error {
+		rec.add("cleanup 1")
+		return nil
+	}, func() error {
+		rec.add("cleanup 2")
+		return nil
+	})
+
+	assert.Nil(t, app.Run())
+	assert.Equal(t, []string{
+		"before start",
+		"register grpc", "register http", "register http",
+		"after start",
+		"before stop",
+		"deregister http", "deregister http", "deregister grpc",
+		"cleanup 2", "cleanup 1",
+		"after stop",
+	}, rec.events)
+
+	services := app.Services()
+	assert.Len(t, services, 3)
+	assert.True(t, strings.HasPrefix(services[0].Addr, "grpc://127.0.0.1:"))
+	assert.True(t, strings.HasPrefix(services[1].Addr, "http://127.0.0.1:"))
+	assert.Equal(t, "waterdrop/stats", services[2].Name)
+
+	// servers are stopped
+	_, err := http.Get(services[1].Addr + "/ping")
+	assert.NotNil(t, err)
+}
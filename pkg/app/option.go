@@ -0,0 +1,166 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package app
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/UnderTreeTech/waterdrop/pkg/registry"
+	httpserver "github.com/UnderTreeTech/waterdrop/pkg/server/http/server"
+	rpcserver "github.com/UnderTreeTech/waterdrop/pkg/server/rpc/server"
+	"github.com/UnderTreeTech/waterdrop/pkg/stats"
+)
+
+// Hook application lifecycle hook
+type Hook func(ctx context.Context) error
+
+// Option application option
+type Option func(*options)
+
+// options application options
+type options struct {
+	name        string
+	version     string
+	metadata    map[string]string
+	registry    registry.Registry
+	httpServer  *httpserver.Server
+	rpcServer   *rpcserver.Server
+	stats       bool
+	statsConfig *stats.Config
+	signals     []os.Signal
+	stopTimeout time.Duration
+
+	beforeStart []Hook
+	afterStart  []Hook
+	beforeStop  []Hook
+	afterStop   []Hook
+}
+
+// defaultOptions default application options
+func defaultOptions() *options {
+	return &options{
+		version:     "1.0.0",
+		stats:       true,
+		signals:     []os.Signal{syscall.SIGTERM, syscall.SIGINT},
+		stopTimeout: 30 * time.Second,
+	}
+}
+
+// Name set application name, servers are registered with it
+func Name(name string) Option {
+	return func(o *options) {
+		o.name = name
+	}
+}
+
+// Version set application version
+func Version(version string) Option {
+	return func(o *options) {
+		o.version = version
+	}
+}
+
+// Metadata set metadata of registered services, which may be used by balancers
+func Metadata(metadata map[string]string) Option {
+	return func(o *options) {
+		o.metadata = metadata
+	}
+}
+
+// Registry set the registry which servers are registered to
+func Registry(registry registry.Registry) Option {
+	return func(o *options) {
+		o.registry = registry
+	}
+}
+
+// HTTPServer set the http server
+func HTTPServer(srv *httpserver.Server) Option {
+	return func(o *options) {
+		o.httpServer = srv
+	}
+}
+
+// RPCServer set the rpc server
+func RPCServer(srv *rpcserver.Server) Option {
+	return func(o *options) {
+		o.rpcServer = srv
+	}
+}
+
+// DisableStats disable the stats server
+func DisableStats() Option {
+	return func(o *options) {
+		o.stats = false
+	}
+}
+
+// StatsConfig set the stats server config
+func StatsConfig(config *stats.Config) Option {
+	return func(o *options) {
+		o.statsConfig = config
+	}
+}
+
+// Signals set signals which trigger shutdown, default SIGTERM and SIGINT.
+// No signal triggers shutdown if signals is empty, the app is stopped by Stop only
+func Signals(signals ...os.Signal) Option {
+	return func(o *options) {
+		o.signals = signals
+	}
+}
+
+// StopTimeout set timeout of running every hook and stopping every server, default 30s
+func StopTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.stopTimeout = timeout
+	}
+}
+
+// BeforeStart add hooks run before servers start, Run aborts if any of them fails
+func BeforeStart(hooks ...Hook) Option {
+	return func(o *options) {
+		o.beforeStart = append(o.beforeStart, hooks...)
+	}
+}
+
+// AfterStart add hooks run after servers start and are registered, Run stops
+// the application if any of them fails
+func AfterStart(hooks ...Hook) Option {
+	return func(o *options) {
+		o.afterStart = append(o.afterStart, hooks...)
+	}
+}
+
+// BeforeStop add hooks run before servers are deregistered and stopped
+func BeforeStop(hooks ...Hook) Option {
+	return func(o *options) {
+		o.beforeStop = append(o.beforeStop, hooks...)
+	}
+}
+
+// AfterStop add hooks run after servers stop and cleanups are done
+func AfterStop(hooks ...Hook) Option {
+	return func(o *options) {
+		o.afterStop = append(o.afterStop, hooks...)
+	}
+}
@@ -103,6 +103,33 @@ func (bg *BreakerGroup) Get(name string) Breaker {
 	return breaker
 }
 
+// Stat breaker stats in the rolling window
+type Stat struct {
+	// State open or closed
+	State   string  `json:"state"`
+	Success float64 `json:"success"`
+	Total   int64   `json:"total"`
+}
+
+// statter breakers which are able to report stats
+type statter interface {
+	stat() Stat
+}
+
+// Stats returns stats of breakers in the group keyed by their names
+func (bg *BreakerGroup) Stats() map[string]Stat {
+	bg.mutex.RLock()
+	defer bg.mutex.RUnlock()
+
+	stats := make(map[string]Stat, len(bg.breakers))
+	for name, breaker := range bg.breakers {
+		if s, ok := breaker.(statter); ok {
+			stats[name] = s.stat()
+		}
+	}
+	return stats
+}
+
 // Do execute the input func and stats the breaker result
 func (bg *BreakerGroup) Do(name string, run func() error, accept func(error) bool) error {
 	breaker := bg.Get(name)
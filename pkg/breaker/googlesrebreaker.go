@@ -113,6 +113,16 @@ func (gsb *googleSreBreaker) summary() (success float64, total int64) {
 	return
 }
 
+// stat returns state and summary of the breaker
+func (gsb *googleSreBreaker) stat() Stat {
+	success, total := gsb.summary()
+	state := "closed"
+	if atomic.LoadInt32(&gsb.state) == StateOpen {
+		state = "open"
+	}
+	return Stat{State: state, Success: success, Total: total}
+}
+
 // Accept indicate request execute successfully
 func (gsb *googleSreBreaker) Accept() {
 	gsb.rw.Add(1)
@@ -70,3 +70,13 @@ func TestBreakerDo(t *testing.T) {
 		})
 	})
 }
+
+// TestBreakerStats test breaker group Stats
+func TestBreakerStats(t *testing.T) {
+	breaker := bg.Get("stats")
+	breaker.Accept()
+	breaker.Reject()
+	stat := bg.Stats()["stats"]
+	assert.Equal(t, float64(1), stat.Success)
+	assert.Equal(t, int64(2), stat.Total)
+}
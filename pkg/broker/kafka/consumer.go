@@ -24,6 +24,7 @@ import (
 	"strconv"
 	"time"
 
+	baggage "github.com/UnderTreeTech/waterdrop/pkg/metadata"
 	"github.com/UnderTreeTech/waterdrop/pkg/stats/metric"
 
 	"github.com/UnderTreeTech/waterdrop/pkg/log"
@@ -49,6 +50,9 @@ type ConsumerConfig struct {
 	EnableReturnError bool
 
 	ClientID string
+	// Version kafka version, default 0.10.2.1. Baggage is carried by message headers
+	// only if version is 0.11.0.0 or later
+	Version string
 }
 
 // Consumer consumer struct
@@ -85,7 +89,7 @@ func newKafkaConsumerConfig(config *ConsumerConfig) *sarama.Config {
 	sconfig.Net.SASL.Password = config.SASLPassword
 	sconfig.Net.SASL.Handshake = config.SASLHandshake
 	sconfig.Net.DialTimeout = config.DialTimeout
-	sconfig.Version = sarama.V0_10_2_1
+	sconfig.Version = kafkaVersion(config.Version)
 	sconfig.Consumer.Return.Errors = config.EnableReturnError
 
 	if config.ConsumeOldest {
@@ -136,10 +140,14 @@ func (c *Consumer) Cleanup(sarama.ConsumerGroupSession) error {
 
 // ConsumeClaim must start a consumer loop of ConsumerGroupClaim's Messages().
 func (c *Consumer) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	partition := strconv.Itoa(int(claim.Partition()))
 	for message := range claim.Messages() {
+		// high water mark is the offset of the next message to be produced
+		metric.KafkaConsumerLag.Set(float64(claim.HighWaterMarkOffset()-message.Offset-1), c.config.Gid, message.Topic, partition)
+		ctx := extractBaggage(context.Background(), message)
 		for _, fn := range c.subscribers {
 			now := time.Now()
-			err := fn(context.Background(), message)
+			err := fn(ctx, message)
 
 			var errmsg string
 			if err != nil {
@@ -178,3 +186,19 @@ func (c *Consumer) ConsumeClaim(session sarama.ConsumerGroupSession, claim saram
 
 	return nil
 }
+
+// extractBaggage extracts baggage from message headers
+func extractBaggage(ctx context.Context, message *sarama.ConsumerMessage) context.Context {
+	if len(message.Headers) == 0 {
+		return ctx
+	}
+
+	return baggage.Extract(ctx, func(key string) string {
+		for _, header := range message.Headers {
+			if string(header.Key) == key {
+				return string(header.Value)
+			}
+		}
+		return ""
+	})
+}
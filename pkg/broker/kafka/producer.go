@@ -24,6 +24,7 @@ import (
 	"strconv"
 	"time"
 
+	baggage "github.com/UnderTreeTech/waterdrop/pkg/metadata"
 	"github.com/UnderTreeTech/waterdrop/pkg/stats/metric"
 
 	"github.com/UnderTreeTech/waterdrop/pkg/log"
@@ -50,12 +51,17 @@ type ProducerConfig struct {
 	EnableReturnSuccess bool
 
 	ClientID string
+	// Version kafka version, default 0.10.2.1. Baggage is carried by message headers
+	// only if version is 0.11.0.0 or later
+	Version string
 }
 
 // SyncProducer send message sync
 type SyncProducer struct {
 	producer sarama.SyncProducer
 	config   *ProducerConfig
+	// headers whether message headers supported
+	headers bool
 
 	interceptors []sarama.ProducerInterceptor
 }
@@ -71,6 +77,7 @@ func NewSyncProducer(config *ProducerConfig) *SyncProducer {
 	sp := &SyncProducer{
 		producer: producer,
 		config:   config,
+		headers:  sconfig.Version.IsAtLeast(sarama.V0_11_0_0),
 	}
 
 	return sp
@@ -85,7 +92,7 @@ func newKafkaProducerConfig(config *ProducerConfig) *sarama.Config {
 	sconfig.Net.SASL.Password = config.SASLPassword
 	sconfig.Net.SASL.Handshake = config.SASLHandshake
 	sconfig.Net.DialTimeout = config.DialTimeout
-	sconfig.Version = sarama.V0_10_2_1
+	sconfig.Version = kafkaVersion(config.Version)
 	sconfig.Producer.Return.Successes = true
 
 	if "" != config.ClientID {
@@ -106,8 +113,15 @@ func (sp *SyncProducer) SendSyncMsg(ctx context.Context, content string) error {
 			Value:     sarama.StringEncoder(content),
 			Timestamp: time.Now(),
 		}
+		if sp.headers {
+			baggage.Inject(ctx, func(key string, val string) {
+				msg.Headers = append(msg.Headers, sarama.RecordHeader{Key: []byte(key), Value: []byte(val)})
+			})
+		}
 
+		metric.KafkaProducerInflight.Inc(topic)
 		partition, offset, err := sp.producer.SendMessage(msg)
+		metric.KafkaProducerInflight.Dec(topic)
 
 		var errmsg string
 		if err != nil {
@@ -148,3 +162,16 @@ func (sp *SyncProducer) SendSyncMsg(ctx context.Context, content string) error {
 func (sp *SyncProducer) Close() error {
 	return sp.producer.Close()
 }
+
+// kafkaVersion parses kafka version, it's default to 0.10.2.1
+func kafkaVersion(version string) sarama.KafkaVersion {
+	if version == "" {
+		return sarama.V0_10_2_1
+	}
+
+	v, err := sarama.ParseKafkaVersion(version)
+	if err != nil {
+		panic(fmt.Sprintf("parse kafka version fail, err msg:%s", err.Error()))
+	}
+	return v
+}
@@ -24,6 +24,8 @@ import (
 	"strings"
 	"time"
 
+	baggage "github.com/UnderTreeTech/waterdrop/pkg/metadata"
+
 	rocketmq "github.com/apache/rocketmq-client-go/v2"
 	"github.com/apache/rocketmq-client-go/v2/consumer"
 	"github.com/apache/rocketmq-client-go/v2/primitive"
@@ -104,7 +106,7 @@ func (pc *PushConsumer) Subscribe(cb func(context.Context, *primitive.MessageExt
 
 	err := pc.consumer.Subscribe(pc.config.Topic, selector, func(ctx context.Context, msgs ...*primitive.MessageExt) (consumer.ConsumeResult, error) {
 		for _, msg := range msgs {
-			err := cb(ctx, msg)
+			err := cb(baggage.Extract(ctx, msg.GetProperty), msg)
 			if err != nil {
 				return consumer.ConsumeRetryLater, err
 			}
@@ -195,7 +197,7 @@ func (pc *PullConsumer) poll(ctx context.Context) {
 		}
 
 		for _, msg := range cr.GetMsgList() {
-			err = pc.cb(ctx, msg)
+			err = pc.cb(baggage.Extract(ctx, msg.GetProperty), msg)
 			if err != nil {
 				break
 			}
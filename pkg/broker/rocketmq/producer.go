@@ -24,6 +24,7 @@ import (
 	"time"
 
 	"github.com/UnderTreeTech/waterdrop/pkg/log"
+	baggage "github.com/UnderTreeTech/waterdrop/pkg/metadata"
 	"github.com/UnderTreeTech/waterdrop/pkg/trace"
 
 	"github.com/UnderTreeTech/waterdrop/pkg/utils/xstring"
@@ -140,6 +141,7 @@ func getSendMsgs(ctx context.Context, topic string, contents []string, tags ...s
 		if len(tags) > 0 {
 			msg = msg.WithTag(tags[0])
 		}
+		baggage.Inject(ctx, msg.WithProperty)
 		msgs = append(msgs, msg)
 	}
 	return msgs
@@ -86,6 +86,9 @@ func Unmarshal(key string, object interface{}) error {
 }
 
 func KeyMap() map[string]interface{} {
+	if defaultConfig == nil {
+		return nil
+	}
 	return defaultConfig.GetKeyMap()
 }
 
@@ -22,6 +22,7 @@ import (
 	"io/ioutil"
 	"log"
 	"path/filepath"
+	"sync"
 	"time"
 
 	fsnotify "gopkg.in/fsnotify.v1"
@@ -31,11 +32,13 @@ import (
 type fileProvider struct {
 	path  string
 	watch bool
+	done  chan struct{}
+	once  sync.Once
 }
 
 // NewFileProvider returns a fileProvider instance
 func NewFileProvider(path string, watch bool) *fileProvider {
-	return &fileProvider{path: filepath.Clean(path), watch: watch}
+	return &fileProvider{path: filepath.Clean(path), watch: watch, done: make(chan struct{})}
 }
 
 // IsEnableWatch check if enable watch file changes
@@ -123,6 +126,9 @@ func (f *fileProvider) Watch(cb func()) error {
 			// There's an error.
 			case err := <-w.Errors:
 				log.Printf("watch file error, err msg %s", err.Error())
+			// Watching is stopped.
+			case <-f.done:
+				break loop
 			}
 		}
 
@@ -132,3 +138,10 @@ func (f *fileProvider) Watch(cb func()) error {
 	// Watch the directory for changes.
 	return w.Add(fDir)
 }
+
+// Close stops watching file changes
+func (f *fileProvider) Close() {
+	f.once.Do(func() {
+		close(f.done)
+	})
+}
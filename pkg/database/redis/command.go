@@ -23,6 +23,8 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/UnderTreeTech/waterdrop/pkg/stats/metric"
+
 	"github.com/spf13/cast"
 
 	"github.com/go-redis/redis/v8"
@@ -30,6 +32,7 @@ import (
 
 // Close closes the client, releasing any open resources
 func (r *Redis) Close() (err error) {
+	metric.UnregisterRedisStats(r.config.DBName, r.config.dbAddr)
 	err = r.client.Close()
 	return
 }
@@ -740,6 +743,16 @@ func (r *Redis) TxPipelined(ctx context.Context, fn func(pipe Pipeliner) error)
 	return
 }
 
+// RunScript runs lua script by EVALSHA, and falls back to EVAL if the script is not loaded
+func (r *Redis) RunScript(ctx context.Context, script *Script, keys []string, args ...interface{}) (value interface{}, err error) {
+	err = r.breakers.Do(r.config.dbAddr, func() error {
+		reply, rerr := script.Run(ctx, r.client, keys, args...).Result()
+		value = reply
+		return rerr
+	}, accept)
+	return
+}
+
 // toPairs transfer redis.Z to Pair
 func (r *Redis) toPairs(zs []redis.Z) (pairs []*Pair) {
 	for _, z := range zs {
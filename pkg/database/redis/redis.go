@@ -116,8 +116,15 @@ type (
 	StringSliceCmd = redis.StringSliceCmd
 	// IntSliceCmd is an alias of redis.IntSliceCmd
 	IntSliceCmd = redis.IntSliceCmd
+	// Script is an alias of redis.Script
+	Script = redis.Script
 )
 
+// NewScript returns a lua script, it's loaded to redis on first run
+func NewScript(src string) *Script {
+	return redis.NewScript(src)
+}
+
 // New returns a redis instance according deploy mode. There are three deploy mode.
 // node: standalone
 // sentinel: master-slave failover sentinel
@@ -164,6 +171,17 @@ func New(cfg *Config) (rdb *Redis, err error) {
 	reference = cfg
 	uc := redis.NewUniversalClient(opts)
 	uc.AddHook(redisHook{})
+	metric.RegisterRedisStats(cfg.DBName, cfg.dbAddr, func() metric.PoolStats {
+		stats := uc.PoolStats()
+		return metric.PoolStats{
+			Hits:       uint64(stats.Hits),
+			Misses:     uint64(stats.Misses),
+			Timeouts:   uint64(stats.Timeouts),
+			TotalConns: uint64(stats.TotalConns),
+			IdleConns:  uint64(stats.IdleConns),
+			StaleConns: uint64(stats.StaleConns),
+		}
+	})
 	rdb = &Redis{
 		client:   uc,
 		config:   cfg,
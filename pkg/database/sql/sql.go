@@ -198,6 +198,7 @@ func Open(c *Config) (*DB, error) {
 	breakers := breaker.NewBreakerGroup()
 	writeBreaker := breakers.Get(addr)
 	w := &conn{DB: d, conf: c, addr: addr, breaker: writeBreaker}
+	metric.RegisterSQLStats(c.DBName, addr, d.Stats)
 	rs := make([]*conn, 0, len(c.ReadDSN))
 	for _, rd := range c.ReadDSN {
 		d, err := connect(c, rd)
@@ -207,6 +208,7 @@ func Open(c *Config) (*DB, error) {
 		addr = c.parseDSNAddr(rd)
 		readBreaker := breakers.Get(addr)
 		r := &conn{DB: d, conf: c, addr: addr, breaker: readBreaker}
+		metric.RegisterSQLStats(c.DBName, addr, d.Stats)
 		rs = append(rs, r)
 	}
 	db.write = w
@@ -294,8 +296,10 @@ func (db *DB) readIndex() int {
 
 // Close closes the write and read database, releasing any open resources.
 func (db *DB) Close() (err error) {
+	metric.UnregisterSQLStats(db.write.conf.DBName, db.write.addr)
 	err = db.write.Close()
 	for _, rd := range db.read {
+		metric.UnregisterSQLStats(rd.conf.DBName, rd.addr)
 		err = rd.Close()
 	}
 
@@ -0,0 +1,40 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package log
+
+import (
+	"net/http"
+
+	"github.com/UnderTreeTech/waterdrop/pkg/stats/admin"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	admin.HandleProtected(http.MethodGet, "/debug/log/level", "log level", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"level": GetLevel()})
+	})
+	admin.HandleProtected(http.MethodPut, "/debug/log/level", "set log level by query level", func(c *gin.Context) {
+		if err := SetLevel(c.Query("level")); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"level": GetLevel()})
+	})
+}
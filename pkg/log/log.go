@@ -36,6 +36,7 @@ import (
 
 	"go.uber.org/zap/zapcore"
 
+	"github.com/UnderTreeTech/waterdrop/pkg/metadata"
 	"github.com/UnderTreeTech/waterdrop/pkg/trace"
 
 	"go.uber.org/zap"
@@ -217,6 +218,21 @@ func (l *Logger) SetLevel(level string) {
 	}
 }
 
+// Level returns log level
+func (l *Logger) Level() string {
+	return l.level.String()
+}
+
+// SetLevel set level of the default logger, it returns error if level is invalid
+func SetLevel(level string) error {
+	return defaultLogger.level.UnmarshalText([]byte(level))
+}
+
+// GetLevel returns level of the default logger
+func GetLevel() string {
+	return defaultLogger.Level()
+}
+
 // Sync flush log
 func (l *Logger) Sync() error {
 	return l.logger.Sync()
@@ -278,11 +294,13 @@ func Panicf(msg string, fields ...Field) {
 
 // assembleFields format log fields
 func assembleFields(ctx context.Context, fields ...Field) []Field {
-	fs := make([]Field, len(fields)+1)
+	fs := make([]Field, 1, len(fields)+2)
 	fs[0] = String("trace_id", trace.TraceID(ctx))
-	copy(fs[1:], fields)
+	if id := metadata.GetRequestID(ctx); id != "" {
+		fs = append(fs, String("request_id", id))
+	}
 
-	return fs
+	return append(fs, fields...)
 }
 
 // rotate rotate log according to the predefined polices
@@ -100,3 +100,11 @@ func TestLog(t *testing.T) {
 		Panicf("memory leaky", String("stop", "yes"))
 	})
 }
+
+func TestLevel(t *testing.T) {
+	defaultLogger = newLogger(defaultConfig())
+	assert.Nil(t, SetLevel("warn"))
+	assert.Equal(t, "warn", GetLevel())
+	assert.NotNil(t, SetLevel("verbose"))
+	assert.Equal(t, "warn", GetLevel())
+}
@@ -0,0 +1,256 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// Package metadata carries business baggage across services. Baggage on the context travels
+// through rpc metadata, http headers and message headers with the key prefix, only the keys in
+// allow-list are propagated and the baggage beyond the size limit is dropped.
+package metadata
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// Tenant tenant of request
+	Tenant = "tenant"
+	// UserID user id of request
+	UserID = "user-id"
+	// AppKey app key of request
+	AppKey = "appkey"
+	// Locale locale of request, such as zh-CN
+	Locale = "locale"
+	// RequestID id of request, it's kept unchanged along the call chain
+	RequestID = "request-id"
+
+	// Prefix key prefix of baggage in rpc metadata, http headers and message headers
+	Prefix = "x-md-"
+	// DefaultMaxSize default max bytes of keys and values propagated
+	DefaultMaxSize = 2048
+)
+
+var (
+	mutex   sync.RWMutex
+	allowed = map[string]struct{}{Tenant: {}, UserID: {}, AppKey: {}, Locale: {}, RequestID: {}}
+	maxSize = DefaultMaxSize
+)
+
+type baggageKey struct{}
+
+// Baggage business key-value pairs, it's immutable once attached to context
+type Baggage map[string]string
+
+// Allow adds keys to allow-list, keys should be lower case
+func Allow(keys ...string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	for _, key := range keys {
+		allowed[key] = struct{}{}
+	}
+}
+
+// Disallow removes keys from allow-list
+func Disallow(keys ...string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	for _, key := range keys {
+		delete(allowed, key)
+	}
+}
+
+// SetMaxSize sets max bytes of keys and values propagated
+func SetMaxSize(size int) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	maxSize = size
+}
+
+// FromContext returns a copy of baggage attached to context
+func FromContext(ctx context.Context) Baggage {
+	b, _ := ctx.Value(baggageKey{}).(Baggage)
+	copied := make(Baggage, len(b))
+	for key, val := range b {
+		copied[key] = val
+	}
+	return copied
+}
+
+// NewContext returns a new context with the baggage merged into the existing one
+func NewContext(ctx context.Context, b Baggage) context.Context {
+	if len(b) == 0 {
+		return ctx
+	}
+
+	merged := FromContext(ctx)
+	for key, val := range b {
+		merged[key] = val
+	}
+	return context.WithValue(ctx, baggageKey{}, merged)
+}
+
+// WithValue returns a new context with the key-value pair
+func WithValue(ctx context.Context, key string, val string) context.Context {
+	return NewContext(ctx, Baggage{key: val})
+}
+
+// Value returns the value of key in baggage
+func Value(ctx context.Context, key string) string {
+	b, _ := ctx.Value(baggageKey{}).(Baggage)
+	return b[key]
+}
+
+// WithTenant returns a new context with the tenant
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return WithValue(ctx, Tenant, tenant)
+}
+
+// GetTenant returns the tenant of request
+func GetTenant(ctx context.Context) string {
+	return Value(ctx, Tenant)
+}
+
+// WithUserID returns a new context with the user id
+func WithUserID(ctx context.Context, uid string) context.Context {
+	return WithValue(ctx, UserID, uid)
+}
+
+// GetUserID returns the user id of request
+func GetUserID(ctx context.Context) string {
+	return Value(ctx, UserID)
+}
+
+// WithAppKey returns a new context with the app key
+func WithAppKey(ctx context.Context, appkey string) context.Context {
+	return WithValue(ctx, AppKey, appkey)
+}
+
+// GetAppKey returns the app key of request
+func GetAppKey(ctx context.Context) string {
+	return Value(ctx, AppKey)
+}
+
+// WithLocale returns a new context with the locale
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return WithValue(ctx, Locale, locale)
+}
+
+// GetLocale returns the locale of request
+func GetLocale(ctx context.Context) string {
+	return Value(ctx, Locale)
+}
+
+// WithRequestID returns a new context with the request id
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return WithValue(ctx, RequestID, id)
+}
+
+// GetRequestID returns the request id
+func GetRequestID(ctx context.Context) string {
+	return Value(ctx, RequestID)
+}
+
+// Inject calls set with the prefixed key of baggage in allow-list, keys are injected
+// in lexical order until the size limit reached
+func Inject(ctx context.Context, set func(key string, val string)) {
+	b, _ := ctx.Value(baggageKey{}).(Baggage)
+	if len(b) == 0 {
+		return
+	}
+
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	keys := make([]string, 0, len(b))
+	for key := range b {
+		if _, ok := allowed[key]; ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	size := 0
+	for _, key := range keys {
+		size += len(key) + len(b[key])
+		if size > maxSize {
+			return
+		}
+		set(Prefix+key, b[key])
+	}
+}
+
+// Extract returns a new context with the baggage in allow-list, get returns the value of prefixed key
+func Extract(ctx context.Context, get func(key string) string) context.Context {
+	mutex.RLock()
+	keys := make([]string, 0, len(allowed))
+	for key := range allowed {
+		keys = append(keys, key)
+	}
+	limit := maxSize
+	mutex.RUnlock()
+	sort.Strings(keys)
+
+	b := make(Baggage)
+	size := 0
+	for _, key := range keys {
+		val := get(Prefix + key)
+		if val == "" {
+			continue
+		}
+
+		size += len(key) + len(val)
+		if size > limit {
+			break
+		}
+		b[key] = val
+	}
+	return NewContext(ctx, b)
+}
+
+// InjectHeader injects baggage into http headers
+func InjectHeader(ctx context.Context, header http.Header) {
+	Inject(ctx, header.Set)
+}
+
+// ExtractHeader extracts baggage from http headers
+func ExtractHeader(ctx context.Context, header http.Header) context.Context {
+	return Extract(ctx, header.Get)
+}
+
+// InjectMD injects baggage into rpc metadata
+func InjectMD(ctx context.Context, md metadata.MD) {
+	Inject(ctx, func(key string, val string) {
+		md.Set(key, val)
+	})
+}
+
+// ExtractMD extracts baggage from rpc metadata
+func ExtractMD(ctx context.Context, md metadata.MD) context.Context {
+	return Extract(ctx, func(key string) string {
+		if values := md.Get(key); len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	})
+}
@@ -0,0 +1,94 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package metadata
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"google.golang.org/grpc/metadata"
+)
+
+func TestBaggage(t *testing.T) {
+	ctx := WithTenant(context.Background(), "waterdrop")
+	ctx = WithUserID(ctx, "10086")
+	ctx = WithAppKey(ctx, "app")
+	ctx = WithLocale(ctx, "zh-CN")
+	assert.Equal(t, "waterdrop", GetTenant(ctx))
+	assert.Equal(t, "10086", GetUserID(ctx))
+	assert.Equal(t, "app", GetAppKey(ctx))
+	assert.Equal(t, "zh-CN", GetLocale(ctx))
+
+	// baggage attached to context is immutable
+	b := FromContext(ctx)
+	b[Tenant] = "other"
+	assert.Equal(t, "waterdrop", GetTenant(ctx))
+	assert.Equal(t, "", GetTenant(context.Background()))
+}
+
+func TestPropagation(t *testing.T) {
+	ctx := WithTenant(context.Background(), "waterdrop")
+	ctx = WithValue(ctx, "secret", "not allowed")
+
+	t.Run("header", func(t *testing.T) {
+		header := http.Header{}
+		InjectHeader(ctx, header)
+		assert.Equal(t, "waterdrop", header.Get("X-Md-Tenant"))
+		assert.Equal(t, "", header.Get("X-Md-Secret"))
+
+		header.Set("X-Md-Secret", "not allowed")
+		extracted := ExtractHeader(context.Background(), header)
+		assert.Equal(t, Baggage{Tenant: "waterdrop"}, FromContext(extracted))
+	})
+
+	t.Run("md", func(t *testing.T) {
+		md := metadata.New(nil)
+		InjectMD(ctx, md)
+		assert.Equal(t, []string{"waterdrop"}, md.Get("x-md-tenant"))
+		assert.Equal(t, "waterdrop", GetTenant(ExtractMD(context.Background(), md)))
+	})
+
+	t.Run("allow", func(t *testing.T) {
+		Allow("secret")
+		defer Disallow("secret")
+
+		header := http.Header{}
+		InjectHeader(ctx, header)
+		assert.Equal(t, "not allowed", header.Get("X-Md-Secret"))
+		assert.Equal(t, "not allowed", Value(ExtractHeader(context.Background(), header), "secret"))
+	})
+
+	t.Run("size limit", func(t *testing.T) {
+		SetMaxSize(32)
+		defer SetMaxSize(DefaultMaxSize)
+
+		large := WithUserID(ctx, strings.Repeat("u", 32))
+		header := http.Header{}
+		InjectHeader(large, header)
+		assert.Equal(t, "waterdrop", header.Get("X-Md-Tenant"))
+		assert.Equal(t, "", header.Get("X-Md-User-Id"))
+
+		header.Set("X-Md-User-Id", strings.Repeat("u", 32))
+		assert.Equal(t, Baggage{Tenant: "waterdrop"}, FromContext(ExtractHeader(context.Background(), header)))
+	})
+}
@@ -0,0 +1,56 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// Package ratelimit provides local token bucket and sliding window limiters, and redis based
+// distributed limiters. All the limiters limit requests by key, such as appkey, ip or route,
+// and they implement Limiter and Taker, which are used by both http middlewares and rpc interceptors.
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// defaultMaxKeys max keys tracked by local limiters, the least recently used keys are evicted
+const defaultMaxKeys = 10240
+
+// Limiter limits requests by key
+type Limiter interface {
+	Allow(context.Context, string) bool
+}
+
+// Taker is a Limiter reporting quota of key, the quota is sent back to callers
+type Taker interface {
+	Limiter
+	// Take takes a token of key and returns the quota after taking
+	Take(ctx context.Context, key string) (*Result, error)
+}
+
+// Result quota of a key after taking a token
+type Result struct {
+	// Allowed whether the request is allowed
+	Allowed bool
+	// Limit max requests allowed in burst or window
+	Limit int64
+	// Remaining requests allowed now
+	Remaining int64
+	// RetryAfter time to wait before the next request allowed, zero if allowed
+	RetryAfter time.Duration
+	// Reset time until the quota fully restored
+	Reset time.Duration
+}
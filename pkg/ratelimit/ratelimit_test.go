@@ -0,0 +1,76 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenBucket(t *testing.T) {
+	ctx := context.Background()
+	tb := NewTokenBucket(10, 3)
+
+	for i := 2; i >= 0; i-- {
+		result, err := tb.Take(ctx, "appkey")
+		assert.Nil(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, int64(3), result.Limit)
+		assert.Equal(t, int64(i), result.Remaining)
+	}
+
+	result, _ := tb.Take(ctx, "appkey")
+	assert.False(t, result.Allowed)
+	assert.True(t, result.RetryAfter > 0 && result.RetryAfter <= 100*time.Millisecond)
+	assert.True(t, tb.Allow(ctx, "another"))
+
+	time.Sleep(110 * time.Millisecond)
+	assert.True(t, tb.Allow(ctx, "appkey"))
+}
+
+func TestSlidingWindow(t *testing.T) {
+	ctx := context.Background()
+	sw := NewSlidingWindow(3, 200*time.Millisecond)
+
+	// start at the beginning of a fixed window
+	time.Sleep(time.Until(time.Now().Truncate(200 * time.Millisecond).Add(200 * time.Millisecond)))
+	for i := 2; i >= 0; i-- {
+		result, err := sw.Take(ctx, "127.0.0.1")
+		assert.Nil(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, int64(i), result.Remaining)
+	}
+
+	result, _ := sw.Take(ctx, "127.0.0.1")
+	assert.False(t, result.Allowed)
+	assert.True(t, result.RetryAfter > 0)
+	assert.True(t, sw.Allow(ctx, "127.0.0.2"))
+
+	// requests of previous window are weighted, quota is partially restored
+	time.Sleep(300 * time.Millisecond)
+	assert.True(t, sw.Allow(ctx, "127.0.0.1"))
+
+	time.Sleep(400 * time.Millisecond)
+	result, _ = sw.Take(ctx, "127.0.0.1")
+	assert.True(t, result.Allowed)
+	assert.Equal(t, int64(2), result.Remaining)
+}
@@ -0,0 +1,162 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/UnderTreeTech/waterdrop/pkg/database/redis"
+	"github.com/UnderTreeTech/waterdrop/pkg/log"
+	"github.com/UnderTreeTech/waterdrop/pkg/utils/xstring"
+)
+
+// errScriptReply unexpected reply of limit script
+var errScriptReply = errors.New("unexpected reply of rate limit script")
+
+// gcraScript generic cell rate algorithm, it keeps theoretical arrival time of key in milliseconds.
+// Time is read from redis, so clock drift of instances doesn't change the limit.
+// ARGV: emission interval, burst. Reply: allowed, remaining, retry after, reset.
+var gcraScript = redis.NewScript(`
+redis.replicate_commands()
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+local interval = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local tolerance = interval * burst
+
+local tat = tonumber(redis.call("GET", KEYS[1]))
+if not tat or tat < now then
+	tat = now
+end
+
+local newTat = tat + interval
+local allowAt = newTat - tolerance
+if now < allowAt then
+	return {0, 0, allowAt - now, tat - now}
+end
+
+redis.call("SET", KEYS[1], newTat, "PX", newTat - now)
+return {1, math.floor((tolerance - newTat + now) / interval), 0, newTat - now}
+`)
+
+// slidingWindowScript sliding log of requests in a sorted set scored by milliseconds of redis time.
+// ARGV: window, limit, member. Reply: allowed, remaining, retry after, reset.
+var slidingWindowScript = redis.NewScript(`
+redis.replicate_commands()
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+local window = tonumber(ARGV[1])
+local limit = tonumber(ARGV[2])
+
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", now - window)
+local count = redis.call("ZCARD", KEYS[1])
+if count >= limit then
+	local oldest = redis.call("ZRANGE", KEYS[1], 0, 0, "WITHSCORES")
+	local retry = window
+	if oldest[2] then
+		retry = tonumber(oldest[2]) + window - now
+	end
+	return {0, 0, retry, window}
+end
+
+redis.call("ZADD", KEYS[1], now, ARGV[3])
+redis.call("PEXPIRE", KEYS[1], window)
+return {1, limit - count - 1, 0, window}
+`)
+
+// RedisLimiter distributed limiter runs limit script on redis, keys are shared by all instances
+type RedisLimiter struct {
+	rdb    *redis.Redis
+	prefix string
+	limit  int64
+	script *redis.Script
+	args   func() []interface{}
+}
+
+// NewRedisGCRA returns a distributed limiter by GCRA, it allows limit requests per period with burst
+func NewRedisGCRA(rdb *redis.Redis, prefix string, limit int64, period time.Duration, burst int64) *RedisLimiter {
+	interval := period.Milliseconds() / limit
+	if interval <= 0 {
+		interval = 1
+	}
+
+	return &RedisLimiter{
+		rdb:    rdb,
+		prefix: prefix,
+		limit:  burst,
+		script: gcraScript,
+		args: func() []interface{} {
+			return []interface{}{interval, burst}
+		},
+	}
+}
+
+// NewRedisSlidingWindow returns a distributed sliding window limiter allowing limit requests in window
+func NewRedisSlidingWindow(rdb *redis.Redis, prefix string, limit int64, window time.Duration) *RedisLimiter {
+	return &RedisLimiter{
+		rdb:    rdb,
+		prefix: prefix,
+		limit:  limit,
+		script: slidingWindowScript,
+		args: func() []interface{} {
+			// member is unique in case of requests in the same millisecond
+			return []interface{}{window.Milliseconds(), limit, xstring.RandomString(16)}
+		},
+	}
+}
+
+// Allow reports whether a request of key may happen now, requests are allowed if redis fails
+func (rl *RedisLimiter) Allow(ctx context.Context, key string) bool {
+	result, err := rl.Take(ctx, key)
+	if err != nil {
+		log.Warn(ctx, "redis rate limit fail, allow request", log.String("key", key), log.String("error", err.Error()))
+		return true
+	}
+	return result.Allowed
+}
+
+// Take takes a token of key by limit script
+func (rl *RedisLimiter) Take(ctx context.Context, key string) (*Result, error) {
+	reply, err := rl.rdb.RunScript(ctx, rl.script, []string{rl.prefix + key}, rl.args()...)
+	if err != nil {
+		return &Result{Allowed: true, Limit: rl.limit, Remaining: rl.limit}, err
+	}
+
+	values, ok := reply.([]interface{})
+	if !ok || len(values) != 4 {
+		return &Result{Allowed: true, Limit: rl.limit, Remaining: rl.limit}, errScriptReply
+	}
+
+	ints := make([]int64, len(values))
+	for i, value := range values {
+		if ints[i], ok = value.(int64); !ok {
+			return &Result{Allowed: true, Limit: rl.limit, Remaining: rl.limit}, errScriptReply
+		}
+	}
+
+	return &Result{
+		Allowed:    ints[0] == 1,
+		Limit:      rl.limit,
+		Remaining:  ints[1],
+		RetryAfter: time.Duration(ints[2]) * time.Millisecond,
+		Reset:      time.Duration(ints[3]) * time.Millisecond,
+	}, nil
+}
@@ -0,0 +1,125 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package setinel
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/UnderTreeTech/waterdrop/pkg/log"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"google.golang.org/grpc"
+)
+
+// EtcdConfig etcd rule source config
+type EtcdConfig struct {
+	Endpoints   []string
+	DialTimeout time.Duration
+	Username    string
+	Password    string
+	// Key rule key, the value is a json array of flow rules or a json object of Rules
+	Key string
+}
+
+// watchEtcd loads rules of etcd key and reloads them on key changes
+func watchEtcd(config *EtcdConfig) error {
+	cli, err := clientv3.New(clientv3.Config{
+		Endpoints:   config.Endpoints,
+		DialTimeout: config.DialTimeout,
+		DialOptions: []grpc.DialOption{grpc.WithBlock()},
+		Username:    config.Username,
+		Password:    config.Password,
+	})
+	if err != nil {
+		return fmt.Errorf("new etcd client fail, err msg %s", err.Error())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	resp, err := cli.Get(ctx, config.Key)
+	if err != nil {
+		cancel()
+		cli.Close()
+		log.Errorf("etcd get rule fail", log.String("key", config.Key), log.String("error", err.Error()))
+		return err
+	}
+
+	var content []byte
+	if len(resp.Kvs) > 0 {
+		content = resp.Kvs[0].Value
+	}
+	if err = Reload(content); err != nil {
+		cancel()
+		cli.Close()
+		return err
+	}
+
+	mutex.Lock()
+	closers = append(closers, func() {
+		cancel()
+		cli.Close()
+	})
+	mutex.Unlock()
+
+	go watchKey(ctx, cli, config.Key, resp.Header.Revision+1)
+	return nil
+}
+
+// watchKey reloads rules on key changes. The watch is re-established once its channel closed
+// or it fails, and the rules are synced from the latest value before watching again.
+func watchKey(ctx context.Context, cli *clientv3.Client, key string, revision int64) {
+	for {
+		wch := cli.Watch(ctx, key, clientv3.WithRev(revision))
+		for event := range wch {
+			if event.Err() != nil {
+				log.Errorf("etcd watch rule fail", log.String("key", key), log.String("error", event.Err().Error()))
+				break
+			}
+
+			for _, ev := range event.Events {
+				revision = ev.Kv.ModRevision + 1
+				if ev.Type == mvccpb.DELETE {
+					log.Warnf("etcd rule deleted, keep the rules loaded", log.String("key", key))
+					continue
+				}
+				Reload(ev.Kv.Value)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+		log.Warnf("etcd watch rule closed, retrying", log.String("key", key))
+
+		// resync in case of events lost, such as revisions compacted
+		resp, err := cli.Get(ctx, key)
+		if err != nil {
+			log.Errorf("etcd get rule fail", log.String("key", key), log.String("error", err.Error()))
+			continue
+		}
+		if len(resp.Kvs) > 0 {
+			Reload(resp.Kvs[0].Value)
+		}
+		revision = resp.Header.Revision + 1
+	}
+}
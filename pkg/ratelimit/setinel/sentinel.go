@@ -19,51 +19,322 @@
 package setinel
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io/ioutil"
+	"sync"
+	"time"
 
 	"github.com/alibaba/sentinel-golang/api"
-
+	cb "github.com/alibaba/sentinel-golang/core/circuitbreaker"
 	sc "github.com/alibaba/sentinel-golang/core/config"
+	"github.com/alibaba/sentinel-golang/core/flow"
+	"github.com/alibaba/sentinel-golang/core/hotspot"
+	"github.com/alibaba/sentinel-golang/core/isolation"
+	"github.com/alibaba/sentinel-golang/core/system"
+	"github.com/alibaba/sentinel-golang/ext/datasource"
 
+	"github.com/UnderTreeTech/waterdrop/pkg/conf/provider/file"
 	"github.com/UnderTreeTech/waterdrop/pkg/log"
-
-	"github.com/alibaba/sentinel-golang/core/flow"
 )
 
 // Config sentinel polices and config
 type Config struct {
-	AppName   string
-	LogPath   string
+	AppName string
+	LogPath string
+	// FlowRules static flow rules, they are merged with the rules of rule source
 	FlowRules []*flow.Rule
-	RulePath  string
+	// CircuitBreakerRules static circuit breaker rules
+	CircuitBreakerRules []*cb.Rule
+	// IsolationRules static concurrency isolation rules
+	IsolationRules []*isolation.Rule
+	// HotspotRules static hotspot parameter rules
+	HotspotRules []*hotspot.Rule
+	// SystemRules static system adaptive rules
+	SystemRules []*system.Rule
+
+	// RulePath rule file, it's a json array of flow rules or a json object of Rules
+	RulePath string
+	// WatchRule whether reload rules on rule file changes
+	WatchRule bool
+	// Etcd rule source of etcd, rules are reloaded on key changes.
+	// Each source replaces all the rules it reloads, so RulePath and Etcd can't be both set
+	Etcd *EtcdConfig
 }
 
-// InitSentinel init sentinel by config
+// Rules all kinds of sentinel rules
+type Rules struct {
+	Flow           []*flow.Rule      `json:"flow"`
+	CircuitBreaker []*cb.Rule        `json:"circuitbreaker"`
+	Isolation      []*isolation.Rule `json:"isolation"`
+	Hotspot        []*hotspot.Rule   `json:"hotspot"`
+	System         []*system.Rule    `json:"system"`
+}
+
+// rawRules rules in rule source, hotspot rules are converted from their json form
+type rawRules struct {
+	Flow           []*flow.Rule      `json:"flow"`
+	CircuitBreaker []*cb.Rule        `json:"circuitbreaker"`
+	Isolation      []*isolation.Rule `json:"isolation"`
+	Hotspot        json.RawMessage   `json:"hotspot"`
+	System         []*system.Rule    `json:"system"`
+}
+
+var (
+	// mutex serializes rule reloading
+	mutex sync.Mutex
+	// static rules of config
+	static *Rules
+	// active rules loaded
+	active *Rules
+	// closers stop watching rule sources
+	closers []func()
+)
+
+// InitSentinel init sentinel by config, rules of config and rule sources are loaded.
+// It returns error if rules can't be read, parsed or loaded.
 func InitSentinel(config *Config) error {
+	if config.RulePath != "" && config.Etcd != nil {
+		return errors.New("sentinel rule path and etcd can't be both set")
+	}
+
+	entity := sc.NewDefaultConfig()
+	entity.Sentinel.App.Name = config.AppName
+	entity.Sentinel.Log.Dir = config.LogPath
+	if err := api.InitWithConfig(entity); err != nil {
+		return err
+	}
+
+	mutex.Lock()
+	static = &Rules{
+		Flow:           config.FlowRules,
+		CircuitBreaker: config.CircuitBreakerRules,
+		Isolation:      config.IsolationRules,
+		Hotspot:        config.HotspotRules,
+		System:         config.SystemRules,
+	}
+	mutex.Unlock()
+
 	if config.RulePath != "" {
-		var rules []*flow.Rule
 		content, err := ioutil.ReadFile(config.RulePath)
 		if err != nil {
 			log.Errorf("read rule fail", log.String("rule_path", config.RulePath), log.String("error", err.Error()))
+			return err
 		}
 
-		if err := json.Unmarshal(content, &rules); err != nil {
-			log.Errorf("unmarshal rule fail", log.String("rule_path", config.RulePath), log.String("error", err.Error()))
+		if err = Reload(content); err != nil {
+			return err
 		}
 
-		config.FlowRules = append(config.FlowRules, rules...)
+		if config.WatchRule {
+			if err = watchFile(config.RulePath); err != nil {
+				log.Errorf("watch rule fail", log.String("rule_path", config.RulePath), log.String("error", err.Error()))
+				return err
+			}
+		}
 	}
 
-	entity := sc.NewDefaultConfig()
-	entity.Sentinel.App.Name = config.AppName
-	entity.Sentinel.Log.Dir = config.LogPath
+	if config.Etcd != nil {
+		return watchEtcd(config.Etcd)
+	}
+
+	if config.RulePath == "" {
+		return Reload(nil)
+	}
+	return nil
+}
+
+// Reload parses rules of rule source and merges them with static rules, then loads all of them.
+// All the rules are validated before loading, so that invalid rules are never partially loaded.
+func Reload(content []byte) error {
+	rules, err := ParseRules(content)
+	if err != nil {
+		log.Errorf("parse rule fail", log.String("error", err.Error()))
+		return err
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if static != nil {
+		rules.Flow = append(append([]*flow.Rule{}, static.Flow...), rules.Flow...)
+		rules.CircuitBreaker = append(append([]*cb.Rule{}, static.CircuitBreaker...), rules.CircuitBreaker...)
+		rules.Isolation = append(append([]*isolation.Rule{}, static.Isolation...), rules.Isolation...)
+		rules.Hotspot = append(append([]*hotspot.Rule{}, static.Hotspot...), rules.Hotspot...)
+		rules.System = append(append([]*system.Rule{}, static.System...), rules.System...)
+	}
+
+	if err = validate(rules); err != nil {
+		log.Errorf("invalid rule", log.String("error", err.Error()))
+		return err
+	}
+
+	previous := active
+	if previous == nil {
+		previous = &Rules{}
+	}
+	if err = load(rules, previous); err != nil {
+		log.Errorf("load rule fail", log.String("error", err.Error()))
+		return err
+	}
+
+	active = rules
+	log.Infof("sentinel rules loaded",
+		log.Int("flow", len(rules.Flow)),
+		log.Int("circuitbreaker", len(rules.CircuitBreaker)),
+		log.Int("isolation", len(rules.Isolation)),
+		log.Int("hotspot", len(rules.Hotspot)),
+		log.Int("system", len(rules.System)),
+	)
+	return nil
+}
 
-	if len(config.FlowRules) > 0 {
-		if _, err := flow.LoadRules(config.FlowRules); err != nil {
-			log.Errorf("load rule fail", log.String("rule_path", config.RulePath), log.String("error", err.Error()))
+// ParseRules parses a json array of flow rules or a json object of Rules
+func ParseRules(content []byte) (*Rules, error) {
+	rules := &Rules{}
+	content = bytes.TrimSpace(content)
+	if len(content) == 0 {
+		return rules, nil
+	}
+
+	if content[0] == '[' {
+		if err := json.Unmarshal(content, &rules.Flow); err != nil {
+			return nil, err
+		}
+		return rules, nil
+	}
+
+	raw := &rawRules{}
+	if err := json.Unmarshal(content, raw); err != nil {
+		return nil, err
+	}
+
+	rules.Flow = raw.Flow
+	rules.CircuitBreaker = raw.CircuitBreaker
+	rules.Isolation = raw.Isolation
+	rules.System = raw.System
+	if len(raw.Hotspot) > 0 {
+		hotspots, err := datasource.HotSpotParamRuleJsonArrayParser(raw.Hotspot)
+		if err != nil {
+			return nil, err
+		}
+		rules.Hotspot, _ = hotspots.([]*hotspot.Rule)
+	}
+	return rules, nil
+}
+
+// GetRules returns the rules loaded
+func GetRules() *Rules {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if active == nil {
+		return &Rules{}
+	}
+	return active
+}
+
+// Close stops watching rule sources
+func Close() {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	for _, closer := range closers {
+		closer()
+	}
+	closers = nil
+}
+
+// validate validates all the rules
+func validate(rules *Rules) error {
+	for _, rule := range rules.Flow {
+		if err := flow.IsValidRule(rule); err != nil {
+			return fmt.Errorf("flow rule %s: %s", rule.Resource, err.Error())
 		}
 	}
+	for _, rule := range rules.CircuitBreaker {
+		if err := cb.IsValid(rule); err != nil {
+			return fmt.Errorf("circuit breaker rule %s: %s", rule.Resource, err.Error())
+		}
+	}
+	for _, rule := range rules.Isolation {
+		if err := isolation.IsValid(rule); err != nil {
+			return fmt.Errorf("isolation rule %s: %s", rule.Resource, err.Error())
+		}
+	}
+	for _, rule := range rules.Hotspot {
+		if err := hotspot.IsValidRule(rule); err != nil {
+			return fmt.Errorf("hotspot rule %s: %s", rule.Resource, err.Error())
+		}
+	}
+	for _, rule := range rules.System {
+		if err := system.IsValidSystemRule(rule); err != nil {
+			return fmt.Errorf("system rule %s: %s", rule.MetricType.String(), err.Error())
+		}
+	}
+	return nil
+}
+
+// loaders load rules of each kind
+var loaders = []func(rules *Rules) error{
+	func(rules *Rules) (err error) {
+		_, err = flow.LoadRules(rules.Flow)
+		return
+	},
+	func(rules *Rules) (err error) {
+		_, err = cb.LoadRules(rules.CircuitBreaker)
+		return
+	},
+	func(rules *Rules) (err error) {
+		_, err = isolation.LoadRules(rules.Isolation)
+		return
+	},
+	func(rules *Rules) (err error) {
+		_, err = hotspot.LoadRules(rules.Hotspot)
+		return
+	},
+	func(rules *Rules) (err error) {
+		_, err = system.LoadRules(rules.System)
+		return
+	},
+}
+
+// load replaces the rules of each kind. Sentinel loads each kind separately, so if any kind
+// fails, the kinds loaded are restored to previous rules, and the reload takes no effect
+func load(rules *Rules, previous *Rules) error {
+	for i, loader := range loaders {
+		err := loader(rules)
+		if err == nil {
+			continue
+		}
+
+		for _, restore := range loaders[:i+1] {
+			if rerr := restore(previous); rerr != nil {
+				log.Errorf("restore rule fail", log.String("error", rerr.Error()))
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+// watchFile reloads rules on rule file changes, the invalid rules are discarded
+func watchFile(path string) error {
+	provider := file.NewFileProvider(path, true)
+	mutex.Lock()
+	closers = append(closers, provider.Close)
+	mutex.Unlock()
 
-	return api.InitWithConfig(entity)
+	return provider.Watch(func() {
+		// wait a moment in case the file is being written
+		time.Sleep(10 * time.Millisecond)
+		content, err := provider.ReadBytes()
+		if err != nil {
+			log.Errorf("read rule fail", log.String("rule_path", path), log.String("error", err.Error()))
+			return
+		}
+		Reload(content)
+	})
 }
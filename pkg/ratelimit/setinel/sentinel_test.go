@@ -19,19 +19,40 @@
 package setinel
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/alibaba/sentinel-golang/api"
 
 	"github.com/alibaba/sentinel-golang/core/base"
+	cb "github.com/alibaba/sentinel-golang/core/circuitbreaker"
+	"github.com/alibaba/sentinel-golang/core/system"
 	"github.com/alibaba/sentinel-golang/util"
 
 	"github.com/alibaba/sentinel-golang/core/flow"
+
+	"github.com/UnderTreeTech/waterdrop/pkg/log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
 )
 
+func TestMain(m *testing.M) {
+	defer log.New(nil).Sync()
+
+	code := m.Run()
+	os.Exit(code)
+}
+
 func TestInitSentinel(t *testing.T) {
 	config := &Config{
 		AppName:   "sentinel",
@@ -70,3 +91,116 @@ func TestInitSentinel(t *testing.T) {
 
 	time.Sleep(time.Second * 1)
 }
+
+const rules = `{
+	"flow": [{"resource": "flow", "threshold": 10, "tokenCalculateStrategy": 0, "controlBehavior": 0, "statIntervalInMs": 1000}],
+	"circuitbreaker": [{"resource": "breaker", "strategy": 2, "retryTimeoutMs": 3000, "minRequestAmount": 10, "statIntervalMs": 1000, "threshold": 0.5}],
+	"isolation": [{"resource": "isolation", "metricType": 0, "threshold": 12}],
+	"hotspot": [{"resource": "hotspot", "metricType": 1, "controlBehavior": 0, "paramIndex": 0, "threshold": 5, "durationInSec": 1,
+		"specificItems": [{"valKind": 1, "valStr": "vip", "threshold": 50}]}],
+	"system": [{"metricType": 1, "triggerCount": 1000, "strategy": 0}]
+}`
+
+func TestParseRules(t *testing.T) {
+	parsed, err := ParseRules([]byte(rules))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(parsed.Flow))
+	assert.Equal(t, 1, len(parsed.CircuitBreaker))
+	assert.Equal(t, 1, len(parsed.Isolation))
+	assert.Equal(t, 1, len(parsed.Hotspot))
+	assert.Equal(t, int64(50), parsed.Hotspot[0].SpecificItems["vip"])
+	assert.Equal(t, 1, len(parsed.System))
+
+	// flow rules array
+	parsed, err = ParseRules([]byte(`[{"resource": "flow", "threshold": 10, "statIntervalInMs": 1000}]`))
+	assert.Nil(t, err)
+	assert.Equal(t, "flow", parsed.Flow[0].Resource)
+
+	_, err = ParseRules([]byte(`{"flow": {}}`))
+	assert.NotNil(t, err)
+}
+
+func TestReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.json")
+	assert.Nil(t, ioutil.WriteFile(path, []byte(rules), 0644))
+
+	err := InitSentinel(&Config{
+		AppName:   "sentinel",
+		FlowRules: []*flow.Rule{{Resource: "static", Threshold: 1, StatIntervalInMs: 1000}},
+		RulePath:  path,
+		WatchRule: true,
+	})
+	assert.Nil(t, err)
+	defer Close()
+
+	loaded := GetRules()
+	assert.Equal(t, 2, len(loaded.Flow))
+	assert.Equal(t, 1, len(loaded.Hotspot))
+	assert.Equal(t, 2, len(flow.GetRules()))
+	assert.Equal(t, 1, len(cb.GetRules()))
+	assert.Equal(t, 1, len(system.GetRules()))
+
+	// invalid rules are discarded and the loaded rules are kept
+	assert.NotNil(t, Reload([]byte(`{"flow": [{"resource": "flow", "threshold": -1}]}`)))
+	assert.Equal(t, loaded, GetRules())
+
+	// rules of all kinds are restored if any kind fails to load
+	hotspotLoader := loaders[3]
+	loaders[3] = func(rules *Rules) error {
+		if rules != loaded {
+			return errors.New("load fail")
+		}
+		return hotspotLoader(rules)
+	}
+	assert.NotNil(t, Reload([]byte(`{"flow": [{"resource": "atomic", "threshold": 5, "statIntervalInMs": 1000}]}`)))
+	loaders[3] = hotspotLoader
+	assert.Equal(t, loaded, GetRules())
+	assert.Equal(t, 0, len(flow.GetRulesOfResource("atomic")))
+	assert.Equal(t, 2, len(flow.GetRules()))
+	assert.Equal(t, 1, len(cb.GetRules()))
+
+	// rule file changes are reloaded
+	assert.Nil(t, ioutil.WriteFile(path, []byte(`[{"resource": "changed", "threshold": 5, "statIntervalInMs": 1000}]`), 0644))
+	assert.Eventually(t, func() bool {
+		return len(cb.GetRules()) == 0 && len(flow.GetRulesOfResource("changed")) == 1
+	}, time.Second, 20*time.Millisecond)
+	assert.Equal(t, 1, len(flow.GetRulesOfResource("static")))
+
+	// rule file changes are not reloaded after closed
+	Close()
+	assert.Nil(t, ioutil.WriteFile(path, []byte(`[{"resource": "closed", "threshold": 5, "statIntervalInMs": 1000}]`), 0644))
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, 0, len(flow.GetRulesOfResource("closed")))
+
+	// rule file and etcd can't be both set
+	assert.NotNil(t, InitSentinel(&Config{AppName: "sentinel", RulePath: path, Etcd: &EtcdConfig{Key: "rules"}}))
+}
+
+func TestRegisterSentinel(t *testing.T) {
+	assert.Nil(t, InitSentinel(&Config{AppName: "sentinel"}))
+	assert.Nil(t, Reload([]byte(rules)))
+
+	e, b := api.Entry("flow", api.WithTrafficType(base.Inbound))
+	assert.Nil(t, b)
+	e.Exit()
+
+	engine := gin.New()
+	RegisterSentinel(engine)
+	req := httptest.NewRequest(http.MethodGet, "/debug/sentinel", nil)
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	reply := struct {
+		Rules map[string][]map[string]interface{} `json:"rules"`
+		Stats []*ResourceStat                     `json:"stats"`
+	}{}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &reply))
+	assert.Equal(t, "vip", reply.Rules["hotspot"][0]["specificItems"].([]interface{})[0].(map[string]interface{})["valStr"])
+	assert.Equal(t, 4, len(reply.Stats))
+	for _, rs := range reply.Stats {
+		if rs.Resource == "flow" {
+			assert.Equal(t, int64(1), rs.Pass)
+		}
+	}
+}
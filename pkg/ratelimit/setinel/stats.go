@@ -0,0 +1,147 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package setinel
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/UnderTreeTech/waterdrop/pkg/stats/admin"
+
+	"github.com/alibaba/sentinel-golang/core/base"
+	"github.com/alibaba/sentinel-golang/core/hotspot"
+	"github.com/alibaba/sentinel-golang/core/stat"
+	"github.com/alibaba/sentinel-golang/ext/datasource"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ResourceStat real-time stats of a resource in the statistic window
+type ResourceStat struct {
+	Resource    string  `json:"resource"`
+	Pass        int64   `json:"pass"`
+	Block       int64   `json:"block"`
+	Complete    int64   `json:"complete"`
+	Error       int64   `json:"error"`
+	Concurrency int32   `json:"concurrency"`
+	AvgRT       float64 `json:"avg_rt"`
+}
+
+func init() {
+	admin.Handle(http.MethodGet, "/debug/sentinel", "sentinel rules and resource stats", rulesHandler)
+}
+
+// RegisterSentinel register handler listing active rules and stats of their resources
+func RegisterSentinel(engine *gin.Engine) {
+	engine.GET("/debug/sentinel", rulesHandler)
+}
+
+// rulesHandler replies active rules and stats of their resources
+func rulesHandler(c *gin.Context) {
+	rules := GetRules()
+	c.JSON(http.StatusOK, gin.H{
+		"rules": gin.H{
+			"flow":           rules.Flow,
+			"circuitbreaker": rules.CircuitBreaker,
+			"isolation":      rules.Isolation,
+			"hotspot":        hotspotView(rules.Hotspot),
+			"system":         rules.System,
+		},
+		"stats": Stats(rules),
+	})
+}
+
+// Stats returns stats of resources with rules
+func Stats(rules *Rules) []*ResourceStat {
+	resources := make(map[string]struct{})
+	for _, rule := range rules.Flow {
+		resources[rule.Resource] = struct{}{}
+	}
+	for _, rule := range rules.CircuitBreaker {
+		resources[rule.Resource] = struct{}{}
+	}
+	for _, rule := range rules.Isolation {
+		resources[rule.Resource] = struct{}{}
+	}
+	for _, rule := range rules.Hotspot {
+		resources[rule.Resource] = struct{}{}
+	}
+
+	stats := make([]*ResourceStat, 0, len(resources))
+	for resource := range resources {
+		rs := &ResourceStat{Resource: resource}
+		if node := stat.GetResourceNode(resource); node != nil {
+			rs.Pass = node.GetSum(base.MetricEventPass)
+			rs.Block = node.GetSum(base.MetricEventBlock)
+			rs.Complete = node.GetSum(base.MetricEventComplete)
+			rs.Error = node.GetSum(base.MetricEventError)
+			rs.Concurrency = node.CurrentConcurrency()
+			rs.AvgRT = node.AvgRT()
+		}
+		stats = append(stats, rs)
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Resource < stats[j].Resource
+	})
+	return stats
+}
+
+// hotspotView converts hotspot rules to their json form, specific items keyed by interface can't be marshaled
+func hotspotView(rules []*hotspot.Rule) []*datasource.HotspotRule {
+	views := make([]*datasource.HotspotRule, 0, len(rules))
+	for _, rule := range rules {
+		view := &datasource.HotspotRule{
+			ID:                rule.ID,
+			Resource:          rule.Resource,
+			MetricType:        rule.MetricType,
+			ControlBehavior:   rule.ControlBehavior,
+			ParamIndex:        rule.ParamIndex,
+			Threshold:         rule.Threshold,
+			MaxQueueingTimeMs: rule.MaxQueueingTimeMs,
+			BurstCount:        rule.BurstCount,
+			DurationInSec:     rule.DurationInSec,
+			ParamsMaxCapacity: rule.ParamsMaxCapacity,
+		}
+		for val, threshold := range rule.SpecificItems {
+			view.SpecificItems = append(view.SpecificItems, datasource.SpecificValue{
+				ValKind:   paramKind(val),
+				ValStr:    fmt.Sprint(val),
+				Threshold: threshold,
+			})
+		}
+		views = append(views, view)
+	}
+	return views
+}
+
+// paramKind returns kind of hotspot parameter value
+func paramKind(val interface{}) datasource.ParamKind {
+	switch val.(type) {
+	case int:
+		return datasource.KindInt
+	case bool:
+		return datasource.KindBool
+	case float64:
+		return datasource.KindFloat64
+	default:
+		return datasource.KindString
+	}
+}
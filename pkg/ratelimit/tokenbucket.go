@@ -0,0 +1,94 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/UnderTreeTech/waterdrop/pkg/utils/xcollection"
+)
+
+// TokenBucket token bucket limiter, tokens are filled at rate per second up to burst
+type TokenBucket struct {
+	rate    float64
+	burst   int64
+	buckets *xcollection.LRUCache
+	mutex   sync.Mutex
+}
+
+// bucket tokens of a key
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewTokenBucket returns a token bucket limiter, rate is the tokens filled per second and
+// burst is the bucket size
+func NewTokenBucket(rate float64, burst int64) *TokenBucket {
+	return &TokenBucket{
+		rate:    rate,
+		burst:   burst,
+		buckets: xcollection.NewLRU(defaultMaxKeys),
+	}
+}
+
+// Allow reports whether a token of key can be taken now
+func (tb *TokenBucket) Allow(ctx context.Context, key string) bool {
+	result, _ := tb.Take(ctx, key)
+	return result.Allowed
+}
+
+// Take takes a token of key if there is one
+func (tb *TokenBucket) Take(_ context.Context, key string) (*Result, error) {
+	tb.mutex.Lock()
+	defer tb.mutex.Unlock()
+
+	now := time.Now()
+	var b *bucket
+	if val, ok := tb.buckets.Get(key); ok {
+		b = val.(*bucket)
+		b.tokens = math.Min(float64(tb.burst), b.tokens+now.Sub(b.last).Seconds()*tb.rate)
+		b.last = now
+	} else {
+		b = &bucket{tokens: float64(tb.burst), last: now}
+		tb.buckets.Add(key, b)
+	}
+
+	result := &Result{Limit: tb.burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = tb.duration(1 - b.tokens)
+	}
+	result.Remaining = int64(b.tokens)
+	result.Reset = tb.duration(float64(tb.burst) - b.tokens)
+	return result, nil
+}
+
+// duration returns the time to fill tokens
+func (tb *TokenBucket) duration(tokens float64) time.Duration {
+	if tb.rate <= 0 {
+		return 0
+	}
+	return time.Duration(tokens / tb.rate * float64(time.Second))
+}
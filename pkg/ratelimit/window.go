@@ -0,0 +1,107 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/UnderTreeTech/waterdrop/pkg/utils/xcollection"
+)
+
+// SlidingWindow sliding window limiter, it allows limit requests in any window. Requests of the
+// previous fixed window are weighted by its overlap with the sliding window, so that only two
+// counters are kept for each key.
+type SlidingWindow struct {
+	limit   int64
+	window  time.Duration
+	windows *xcollection.LRUCache
+	mutex   sync.Mutex
+}
+
+// counter requests of the current and previous fixed window of a key
+type counter struct {
+	start    time.Time
+	current  int64
+	previous int64
+}
+
+// NewSlidingWindow returns a sliding window limiter allowing limit requests in window
+func NewSlidingWindow(limit int64, window time.Duration) *SlidingWindow {
+	return &SlidingWindow{
+		limit:   limit,
+		window:  window,
+		windows: xcollection.NewLRU(defaultMaxKeys),
+	}
+}
+
+// Allow reports whether a request of key may happen now
+func (sw *SlidingWindow) Allow(ctx context.Context, key string) bool {
+	result, _ := sw.Take(ctx, key)
+	return result.Allowed
+}
+
+// Take counts a request of key if the window is not full
+func (sw *SlidingWindow) Take(_ context.Context, key string) (*Result, error) {
+	sw.mutex.Lock()
+	defer sw.mutex.Unlock()
+
+	now := time.Now()
+	start := now.Truncate(sw.window)
+	var c *counter
+	if val, ok := sw.windows.Get(key); ok {
+		c = val.(*counter)
+	} else {
+		c = &counter{start: start}
+		sw.windows.Add(key, c)
+	}
+
+	// slide the fixed windows
+	switch elapsed := start.Sub(c.start); {
+	case elapsed >= 2*sw.window:
+		c.previous, c.current = 0, 0
+	case elapsed >= sw.window:
+		c.previous, c.current = c.current, 0
+	}
+	c.start = start
+
+	reset := sw.window - now.Sub(start)
+	weight := float64(reset) / float64(sw.window)
+	count := int64(float64(c.previous)*weight) + c.current
+
+	result := &Result{Limit: sw.limit, Reset: reset}
+	if count < sw.limit {
+		c.current++
+		result.Allowed = true
+		result.Remaining = sw.limit - count - 1
+		return result, nil
+	}
+
+	// wait until the weighted requests of previous window drop or the window slides
+	result.RetryAfter = reset
+	if c.previous > 0 && c.current < sw.limit {
+		drop := float64(count-sw.limit+1) / float64(c.previous)
+		result.RetryAfter = time.Duration(drop * float64(sw.window))
+		if result.RetryAfter > reset {
+			result.RetryAfter = reset
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,43 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package registry
+
+import "context"
+
+// ColorHeader http header and grpc metadata key which carries the routing color
+const ColorHeader = "X-Color"
+
+type colorKey struct{}
+
+// WithColor returns a new context carries the routing color. Requests with color are
+// routed to instances whose color metadata equals to it, and the color is passed to
+// downstream services along with the call chain.
+func WithColor(ctx context.Context, color string) context.Context {
+	return context.WithValue(ctx, colorKey{}, color)
+}
+
+// ColorFromContext returns the routing color carried by ctx
+func ColorFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+
+	color, _ := ctx.Value(colorKey{}).(string)
+	return color
+}
@@ -216,6 +216,48 @@ func (e *EtcdRegistry) List(ctx context.Context, name string, scheme string) (se
 	return
 }
 
+// Watch watches instance changes of the service, see registry.Watcher for details.
+// The watch is re-established on channel close, and a notification is sent after
+// that since changes may be missed during reconnecting.
+func (e *EtcdRegistry) Watch(ctx context.Context, name string) (<-chan struct{}, error) {
+	watchKey := fmt.Sprintf("/%s/%s/", e.config.Prefix, name)
+	ch := make(chan struct{}, 1)
+	notify := func() {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+
+	go func() {
+		defer close(ch)
+		for {
+			for resp := range e.client.Watch(ctx, watchKey, clientv3.WithPrefix()) {
+				if len(resp.Events) > 0 {
+					notify()
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Second):
+			}
+			log.Warnf("etcd watch channel closed, retrying", log.String("watch_key", watchKey))
+			notify()
+		}
+	}()
+
+	return ch, nil
+}
+
+// Ping checks if the etcd session is alive, it reads a key so that both
+// connectivity and authentication are verified
+func (e *EtcdRegistry) Ping(ctx context.Context) error {
+	_, err := e.client.Get(ctx, fmt.Sprintf("/%s/health", e.config.Prefix), clientv3.WithCountOnly())
+	return err
+}
+
 // Close close connection to etcd and deRegister all service info
 func (e *EtcdRegistry) Close() {
 	var wg sync.WaitGroup
@@ -371,10 +413,19 @@ func (r *etcdResolver) getAddrs(services []*registry.ServiceInfo) []resolver.Add
 			continue
 		}
 
+		// attach service metadata to address, balancers make decision by them
+		attrs := attributes.New("scheme", u.Scheme)
+		for key, val := range service.Metadata {
+			attrs = attrs.WithValues(key, val)
+		}
+		if _, ok := service.Metadata[registry.MetaZone]; !ok && service.Zone != "" {
+			attrs = attrs.WithValues(registry.MetaZone, service.Zone)
+		}
+
 		addr := resolver.Address{
 			Addr:       u.Host,
 			ServerName: service.Name,
-			Attributes: attributes.New("scheme", u.Scheme),
+			Attributes: attrs,
 		}
 		addrs = append(addrs, addr)
 	}
@@ -35,6 +35,13 @@ type Registry interface {
 	Close()
 }
 
+// Watcher is implemented by registries which are able to notify service changes
+type Watcher interface {
+	// Watch returns a channel which receives a notification when instances of the
+	// service register or deregister, the channel is closed when ctx is done
+	Watch(ctx context.Context, name string) (<-chan struct{}, error)
+}
+
 // ServiceInfo service metadata definition
 type ServiceInfo struct {
 	// Service Name
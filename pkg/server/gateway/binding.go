@@ -0,0 +1,145 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package gateway
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// fieldByName finds field by proto name or json name
+func fieldByName(msg protoreflect.Message, name string) (protoreflect.FieldDescriptor, error) {
+	fields := msg.Descriptor().Fields()
+	fd := fields.ByName(protoreflect.Name(name))
+	if fd == nil {
+		fd = fields.ByJSONName(name)
+	}
+
+	if fd == nil {
+		return nil, fmt.Errorf("field %s not found in %s", name, msg.Descriptor().FullName())
+	}
+	return fd, nil
+}
+
+// mutableMessage returns the message field of msg by field path like `a.b.c`
+func mutableMessage(msg protoreflect.Message, path string) (protoreflect.Message, error) {
+	for _, name := range strings.Split(path, ".") {
+		fd, err := fieldByName(msg, name)
+		if err != nil {
+			return nil, err
+		}
+
+		if fd.Message() == nil || fd.IsList() || fd.IsMap() {
+			return nil, fmt.Errorf("field %s is not a message", fd.FullName())
+		}
+		msg = msg.Mutable(fd).Message()
+	}
+	return msg, nil
+}
+
+// setField sets the scalar field of msg by field path like `a.b.c`.
+// Values are appended if the field is repeated, otherwise the last value is used.
+func setField(msg protoreflect.Message, path string, values ...string) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	names := strings.Split(path, ".")
+	if len(names) > 1 {
+		var err error
+		if msg, err = mutableMessage(msg, strings.Join(names[:len(names)-1], ".")); err != nil {
+			return err
+		}
+	}
+
+	fd, err := fieldByName(msg, names[len(names)-1])
+	if err != nil {
+		return err
+	}
+
+	if fd.IsMap() {
+		return fmt.Errorf("map field %s is not supported", fd.FullName())
+	}
+
+	if fd.IsList() {
+		list := msg.Mutable(fd).List()
+		for _, value := range values {
+			v, err := parseValue(fd, value)
+			if err != nil {
+				return err
+			}
+			list.Append(v)
+		}
+		return nil
+	}
+
+	v, err := parseValue(fd, values[len(values)-1])
+	if err != nil {
+		return err
+	}
+	msg.Set(fd, v)
+	return nil
+}
+
+// parseValue parses the string to the value of field kind
+func parseValue(fd protoreflect.FieldDescriptor, value string) (protoreflect.Value, error) {
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(value), nil
+	case protoreflect.BoolKind:
+		b, err := strconv.ParseBool(value)
+		return protoreflect.ValueOfBool(b), err
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		i, err := strconv.ParseInt(value, 10, 32)
+		return protoreflect.ValueOfInt32(int32(i)), err
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		i, err := strconv.ParseInt(value, 10, 64)
+		return protoreflect.ValueOfInt64(i), err
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		u, err := strconv.ParseUint(value, 10, 32)
+		return protoreflect.ValueOfUint32(uint32(u)), err
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		u, err := strconv.ParseUint(value, 10, 64)
+		return protoreflect.ValueOfUint64(u), err
+	case protoreflect.FloatKind:
+		f, err := strconv.ParseFloat(value, 32)
+		return protoreflect.ValueOfFloat32(float32(f)), err
+	case protoreflect.DoubleKind:
+		f, err := strconv.ParseFloat(value, 64)
+		return protoreflect.ValueOfFloat64(f), err
+	case protoreflect.BytesKind:
+		b, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			b, err = base64.URLEncoding.DecodeString(value)
+		}
+		return protoreflect.ValueOfBytes(b), err
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByName(protoreflect.Name(value)); ev != nil {
+			return protoreflect.ValueOfEnum(ev.Number()), nil
+		}
+		i, err := strconv.ParseInt(value, 10, 32)
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(i)), err
+	}
+
+	return protoreflect.Value{}, fmt.Errorf("field %s of kind %s is not supported", fd.FullName(), fd.Kind())
+}
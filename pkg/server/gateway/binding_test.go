@@ -0,0 +1,60 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package gateway
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"google.golang.org/grpc/test/grpc_testing"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestSetField(t *testing.T) {
+	req := &grpc_testing.SimpleRequest{}
+	msg := req.ProtoReflect()
+
+	assert.Nil(t, setField(msg, "response_type", "COMPRESSABLE"))
+	assert.Nil(t, setField(msg, "responseSize", "1", "10"))
+	assert.Nil(t, setField(msg, "fill_username", "true"))
+	assert.Nil(t, setField(msg, "payload.body", "d2F0ZXJkcm9w"))
+	assert.Equal(t, grpc_testing.PayloadType_COMPRESSABLE, req.ResponseType)
+	assert.Equal(t, int32(10), req.ResponseSize)
+	assert.True(t, req.FillUsername)
+	assert.Equal(t, []byte("waterdrop"), req.Payload.Body)
+
+	assert.NotNil(t, setField(msg, "response_size", "ten"))
+	assert.NotNil(t, setField(msg, "not_exist", "1"))
+	assert.NotNil(t, setField(msg, "response_size.value", "1"))
+
+	file := &descriptorpb.FileDescriptorProto{}
+	assert.Nil(t, setField(file.ProtoReflect(), "public_dependency", "1", "2"))
+	assert.Equal(t, []int32{1, 2}, file.PublicDependency)
+}
+
+func TestMutableMessage(t *testing.T) {
+	req := &grpc_testing.SimpleRequest{}
+	payload, err := mutableMessage(req.ProtoReflect(), "payload")
+	assert.Nil(t, err)
+	assert.Equal(t, "grpc.testing.Payload", string(payload.Descriptor().FullName()))
+
+	_, err = mutableMessage(req.ProtoReflect(), "response_size")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,375 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/UnderTreeTech/waterdrop/pkg/log"
+	"github.com/UnderTreeTech/waterdrop/pkg/status"
+
+	"github.com/UnderTreeTech/waterdrop/pkg/server/http/metadata"
+	hserver "github.com/UnderTreeTech/waterdrop/pkg/server/http/server"
+	"github.com/UnderTreeTech/waterdrop/pkg/server/rpc/interceptors"
+	rserver "github.com/UnderTreeTech/waterdrop/pkg/server/rpc/server"
+
+	"github.com/gin-gonic/gin"
+
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/grpc"
+	gmetadata "google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+const (
+	// bufSize in memory connection buffer size
+	bufSize = 1024 * 1024
+	// bodyAll the whole request body is mapped to request message
+	bodyAll = "*"
+	// MetadataHeaderPrefix http headers with the prefix are passed to grpc services as metadata
+	MetadataHeaderPrefix = "Grpc-Metadata-"
+)
+
+var (
+	// forwardHeaders http headers passed to grpc services as metadata
+	forwardHeaders = []string{"Authorization", metadata.HeaderAppkey, metadata.HeaderAcceptLanguage}
+
+	marshaler   = protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}
+	unmarshaler = protojson.UnmarshalOptions{DiscardUnknown: true}
+)
+
+// Gateway transcodes http/json requests to the grpc services registered on rpc server.
+// The requests go through the middlewares of http server and the interceptors of rpc server.
+type Gateway struct {
+	conn *grpc.ClientConn
+}
+
+// route a http route mapped to grpc method
+type route struct {
+	httpMethod string
+	path       string
+	body       string
+	fullMethod string
+	input      protoreflect.MessageType
+	output     protoreflect.MessageType
+}
+
+// Register exposes the unary methods of the grpc services registered on rpc server over http server.
+// Every method is exposed as `POST /{package.Service}/{Method}` with json body, plus the routes
+// declared by its google.api.http annotation.
+// Notice that Register must be called after all the services are registered on rpc server.
+func Register(hs *hserver.Server, rs *rserver.Server) (*Gateway, error) {
+	routes := make([]*route, 0)
+	services := make([]string, 0)
+	for name := range rs.Server().GetServiceInfo() {
+		services = append(services, name)
+	}
+	sort.Strings(services)
+
+	for _, name := range services {
+		desc, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(name))
+		if err != nil {
+			log.Warnf("gateway skip service without descriptor", log.String("service", name), log.String("error", err.Error()))
+			continue
+		}
+
+		sd, ok := desc.(protoreflect.ServiceDescriptor)
+		if !ok {
+			continue
+		}
+
+		for i := 0; i < sd.Methods().Len(); i++ {
+			md := sd.Methods().Get(i)
+			if md.IsStreamingClient() || md.IsStreamingServer() {
+				continue
+			}
+			routes = append(routes, parseRoutes(md)...)
+		}
+	}
+
+	if err := checkConflicts(hs.Routes(), routes); err != nil {
+		return nil, err
+	}
+
+	// services can't be registered once the rpc server serves, so reflection registered
+	// by rpc server Start is registered ahead
+	if _, ok := rs.Server().GetServiceInfo()[reflectionpb.ServerReflection_ServiceDesc.ServiceName]; !ok {
+		reflection.Register(rs.Server())
+	}
+
+	listener := bufconn.Listen(bufSize)
+	go func() {
+		if err := rs.Server().Serve(listener); err != nil && err != grpc.ErrServerStopped {
+			log.Errorf("gateway serve fail", log.String("error", err.Error()))
+		}
+	}()
+
+	conn, err := grpc.Dial(
+		"gateway",
+		grpc.WithInsecure(),
+		grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+			return listener.Dial()
+		}),
+		grpc.WithUnaryInterceptor(interceptors.TraceForUnaryClient()),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	gw := &Gateway{conn: conn}
+	for _, r := range routes {
+		hs.Handle(r.httpMethod, r.path, gw.handle(r))
+	}
+
+	return gw, nil
+}
+
+// checkConflicts checks whether the gateway routes conflict with each other or with the registered
+// routes, gin panics on conflict routes such as a static segment against a param segment
+func checkConflicts(registered gin.RoutesInfo, routes []*route) (err error) {
+	var current *route
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("gateway route %s %s of %s conflicts: %v", current.httpMethod, current.path, current.fullMethod, r)
+		}
+	}()
+
+	engine := gin.New()
+	noop := func(*gin.Context) {}
+	for _, r := range registered {
+		engine.Handle(r.Method, r.Path, noop)
+	}
+
+	for _, current = range routes {
+		engine.Handle(current.httpMethod, current.path, noop)
+	}
+	return nil
+}
+
+// Close closes the in memory connection to rpc server
+func (gw *Gateway) Close() error {
+	return gw.conn.Close()
+}
+
+// handle transcodes http request to grpc request and renders the grpc reply as json
+func (gw *Gateway) handle(r *route) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		in := r.input.New()
+		if err := bind(c, r, in); err != nil {
+			log.Warn(c.Request.Context(), "gateway bind request fail", log.String("path", c.Request.URL.Path), log.String("error", err.Error()))
+			renderStatus(c, status.RequestErr)
+			return
+		}
+
+		out := r.output.New().Interface()
+		ctx := gmetadata.NewOutgoingContext(c.Request.Context(), headerToMetadata(c.Request.Header))
+		if err := gw.conn.Invoke(ctx, r.fullMethod, in.Interface(), out); err != nil {
+			renderStatus(c, status.ExtractStatus(err))
+			return
+		}
+
+		data, err := marshaler.Marshal(out)
+		if err != nil {
+			renderStatus(c, status.ServerErr)
+			return
+		}
+		c.Data(http.StatusOK, metadata.DefaultContentTypeJson, data)
+	}
+}
+
+// bind maps request body, path params and query params to the request message
+func bind(c *gin.Context, r *route, msg protoreflect.Message) error {
+	if r.body != "" {
+		body, err := ioutil.ReadAll(c.Request.Body)
+		if err != nil {
+			return err
+		}
+
+		if len(body) > 0 {
+			target := msg
+			if r.body != bodyAll {
+				if target, err = mutableMessage(msg, r.body); err != nil {
+					return err
+				}
+			}
+
+			if err = unmarshaler.Unmarshal(body, target.Interface()); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, param := range c.Params {
+		// value of catch-all param starts with slash
+		if err := setField(msg, param.Key, strings.TrimPrefix(param.Value, "/")); err != nil {
+			return err
+		}
+	}
+
+	if r.body == bodyAll {
+		return nil
+	}
+
+	for key, values := range c.Request.URL.Query() {
+		if err := setField(msg, key, values...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// headerToMetadata picks the http headers passed to grpc services
+func headerToMetadata(header http.Header) gmetadata.MD {
+	md := gmetadata.MD{}
+	for key, values := range header {
+		if strings.HasPrefix(key, MetadataHeaderPrefix) {
+			md.Append(strings.TrimPrefix(key, MetadataHeaderPrefix), values...)
+		}
+	}
+
+	for _, key := range forwardHeaders {
+		if values := header.Values(key); len(values) > 0 {
+			md.Append(key, values...)
+		}
+	}
+	return md
+}
+
+// renderStatus renders the status in the response envelope of http server
+func renderStatus(c *gin.Context, s *status.Status) {
+	hserver.Render(c, nil, s)
+	c.Abort()
+}
+
+// parseRoutes returns the http routes of grpc method
+func parseRoutes(md protoreflect.MethodDescriptor) []*route {
+	fullMethod := fmt.Sprintf("/%s/%s", md.Parent().FullName(), md.Name())
+	input, err := protoregistry.GlobalTypes.FindMessageByName(md.Input().FullName())
+	if err != nil {
+		log.Warnf("gateway skip method without input type", log.String("method", fullMethod), log.String("error", err.Error()))
+		return nil
+	}
+
+	output, err := protoregistry.GlobalTypes.FindMessageByName(md.Output().FullName())
+	if err != nil {
+		log.Warnf("gateway skip method without output type", log.String("method", fullMethod), log.String("error", err.Error()))
+		return nil
+	}
+
+	routes := []*route{{
+		httpMethod: http.MethodPost,
+		path:       fullMethod,
+		body:       bodyAll,
+		fullMethod: fullMethod,
+		input:      input,
+		output:     output,
+	}}
+
+	opts, ok := md.Options().(*descriptorpb.MethodOptions)
+	if !ok || opts == nil || !proto.HasExtension(opts, annotations.E_Http) {
+		return routes
+	}
+
+	rule := proto.GetExtension(opts, annotations.E_Http).(*annotations.HttpRule)
+	rules := append([]*annotations.HttpRule{rule}, rule.GetAdditionalBindings()...)
+	for _, rule := range rules {
+		httpMethod, template := httpPattern(rule)
+		path, err := ginPath(template)
+		if err != nil {
+			log.Warnf("gateway skip http rule", log.String("method", fullMethod), log.String("error", err.Error()))
+			continue
+		}
+
+		routes = append(routes, &route{
+			httpMethod: httpMethod,
+			path:       path,
+			body:       rule.GetBody(),
+			fullMethod: fullMethod,
+			input:      input,
+			output:     output,
+		})
+	}
+	return routes
+}
+
+// httpPattern returns http method and path template of http rule
+func httpPattern(rule *annotations.HttpRule) (method string, template string) {
+	switch pattern := rule.GetPattern().(type) {
+	case *annotations.HttpRule_Get:
+		return http.MethodGet, pattern.Get
+	case *annotations.HttpRule_Put:
+		return http.MethodPut, pattern.Put
+	case *annotations.HttpRule_Post:
+		return http.MethodPost, pattern.Post
+	case *annotations.HttpRule_Delete:
+		return http.MethodDelete, pattern.Delete
+	case *annotations.HttpRule_Patch:
+		return http.MethodPatch, pattern.Patch
+	case *annotations.HttpRule_Custom:
+		return strings.ToUpper(pattern.Custom.GetKind()), pattern.Custom.GetPath()
+	}
+	return "", ""
+}
+
+// ginPath converts path template of http rule to gin path. `{field}` and `{field=*}` are
+// converted to `:field`, `{field=**}` in the last segment is converted to `*field`.
+// Other variable patterns and custom verbs are not supported.
+func ginPath(template string) (string, error) {
+	if !strings.HasPrefix(template, "/") {
+		return "", fmt.Errorf("invalid path template %s", template)
+	}
+
+	segments := strings.Split(template[1:], "/")
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, "{") {
+			if strings.ContainsAny(segment, "{}:*") {
+				return "", fmt.Errorf("unsupported path template %s", template)
+			}
+			continue
+		}
+
+		if !strings.HasSuffix(segment, "}") {
+			return "", fmt.Errorf("unsupported path template %s", template)
+		}
+
+		variable := strings.SplitN(segment[1:len(segment)-1], "=", 2)
+		switch {
+		case len(variable) == 1 || variable[1] == "*":
+			segments[i] = ":" + variable[0]
+		case variable[1] == "**" && i == len(segments)-1:
+			segments[i] = "*" + variable[0]
+		default:
+			return "", fmt.Errorf("unsupported path template %s", template)
+		}
+	}
+	return "/" + strings.Join(segments, "/"), nil
+}
@@ -0,0 +1,212 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package gateway
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/UnderTreeTech/waterdrop/pkg/log"
+	"github.com/UnderTreeTech/waterdrop/pkg/status"
+	"github.com/UnderTreeTech/waterdrop/tests/proto/demo"
+
+	hserver "github.com/UnderTreeTech/waterdrop/pkg/server/http/server"
+	rconfig "github.com/UnderTreeTech/waterdrop/pkg/server/rpc/config"
+	rserver "github.com/UnderTreeTech/waterdrop/pkg/server/rpc/server"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func TestGateway(t *testing.T) {
+	defer log.New(nil).Sync()
+
+	rs := rserver.New(nil)
+	demo.RegisterDemoServer(rs.Server(), &service{})
+	hs := hserver.New(nil)
+	gw, err := Register(hs, rs)
+	assert.Nil(t, err)
+	defer gw.Close()
+	defer rs.Stop(context.Background())
+
+	t.Run("success", func(t *testing.T) {
+		w := serve(hs, http.MethodPost, "/service.demo.v1.Demo/SayHelloURL", `{"name":"waterdrop"}`, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"Content":"Hello waterdrop"}`, w.Body.String())
+	})
+
+	t.Run("metadata", func(t *testing.T) {
+		header := http.Header{}
+		header.Set(MetadataHeaderPrefix+"Name", "metadata")
+		w := serve(hs, http.MethodPost, "/service.demo.v1.Demo/SayHelloURL", "", header)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"Content":"Hello metadata"}`, w.Body.String())
+	})
+
+	t.Run("status", func(t *testing.T) {
+		w := serve(hs, http.MethodPost, "/service.demo.v1.Demo/SayHelloURL", `{"name":"nobody"}`, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.JSONEq(t, `{"code":404,"message":"nothing found","data":null,"trace_id":""}`, w.Body.String())
+	})
+
+	t.Run("bad request", func(t *testing.T) {
+		w := serve(hs, http.MethodPost, "/service.demo.v1.Demo/SayHelloURL", `{"name":`, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestGatewayStart(t *testing.T) {
+	defer log.New(nil).Sync()
+
+	cfg := rconfig.DefaultServerConfig()
+	cfg.Addr = "127.0.0.1:0"
+	rs := rserver.New(cfg)
+	demo.RegisterDemoServer(rs.Server(), &service{})
+	gw, err := Register(hserver.New(nil), rs)
+	assert.Nil(t, err)
+	defer gw.Close()
+	defer rs.Stop(context.Background())
+
+	// rpc server starts after the gateway serves it in process
+	assert.NotNil(t, rs.Start())
+	_, ok := rs.Server().GetServiceInfo()["grpc.reflection.v1alpha.ServerReflection"]
+	assert.True(t, ok)
+}
+
+func TestAnnotation(t *testing.T) {
+	defer log.New(nil).Sync()
+
+	rs := rserver.New(nil)
+	demo.RegisterDemoServer(rs.Server(), &service{})
+	hs := hserver.New(nil)
+	gw, err := Register(hs, rs)
+	assert.Nil(t, err)
+	defer gw.Close()
+	defer rs.Stop(context.Background())
+
+	// the descriptor of demo service annotated with google.api.http
+	opts := &descriptorpb.MethodOptions{}
+	proto.SetExtension(opts, annotations.E_Http, &annotations.HttpRule{
+		Pattern: &annotations.HttpRule_Get{Get: "/v1/hello/{name}"},
+		AdditionalBindings: []*annotations.HttpRule{
+			{Pattern: &annotations.HttpRule_Post{Post: "/v1/hello"}, Body: "*"},
+		},
+	})
+	fd, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:       proto.String("annotation.proto"),
+		Package:    proto.String("service.demo.v1"),
+		Dependency: []string{"demo.proto"},
+		Syntax:     proto.String("proto3"),
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("Demo"),
+			Method: []*descriptorpb.MethodDescriptorProto{{
+				Name:       proto.String("SayHelloURL"),
+				InputType:  proto.String(".service.demo.v1.HelloReq"),
+				OutputType: proto.String(".service.demo.v1.HelloResp"),
+				Options:    opts,
+			}},
+		}},
+	}, protoregistry.GlobalFiles)
+	assert.Nil(t, err)
+
+	routes := parseRoutes(fd.Services().Get(0).Methods().Get(0))
+	assert.Equal(t, 3, len(routes))
+	for _, r := range routes[1:] {
+		hs.Handle(r.httpMethod, r.path, gw.handle(r))
+	}
+
+	w := serve(hs, http.MethodGet, "/v1/hello/waterdrop", "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"Content":"Hello waterdrop"}`, w.Body.String())
+
+	w = serve(hs, http.MethodPost, "/v1/hello", `{"name":"annotation"}`, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"Content":"Hello annotation"}`, w.Body.String())
+}
+
+func TestCheckConflicts(t *testing.T) {
+	registered := gin.RoutesInfo{{Method: http.MethodGet, Path: "/v1/hello/:name"}}
+	routes := []*route{{httpMethod: http.MethodPost, path: "/v1/hello", fullMethod: "/service.demo.v1.Demo/SayHelloURL"}}
+	assert.Nil(t, checkConflicts(registered, routes))
+
+	routes = append(routes, &route{httpMethod: http.MethodGet, path: "/v1/hello/:id", fullMethod: "/service.demo.v1.Demo/SayHello"})
+	err := checkConflicts(registered, routes)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "/service.demo.v1.Demo/SayHello")
+}
+
+func TestGinPath(t *testing.T) {
+	cases := map[string]string{
+		"/v1/users":                   "/v1/users",
+		"/v1/users/{id}":              "/v1/users/:id",
+		"/v1/users/{user.id=*}/books": "/v1/users/:user.id/books",
+		"/v1/files/{path=**}":         "/v1/files/*path",
+	}
+	for template, expected := range cases {
+		path, err := ginPath(template)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, path)
+	}
+
+	for _, template := range []string{"v1/users", "/v1/{name=shelves/*}", "/v1/users:batchGet", "/v1/{path=**}/end"} {
+		_, err := ginPath(template)
+		assert.NotNil(t, err)
+	}
+}
+
+// serve serves the request by http server
+func serve(hs *hserver.Server, method string, path string, body string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	w := httptest.NewRecorder()
+	hs.ServeHTTP(w, req)
+	return w
+}
+
+type service struct{}
+
+func (s *service) SayHello(ctx context.Context, req *demo.HelloReq) (reply *emptypb.Empty, err error) {
+	return &emptypb.Empty{}, nil
+}
+
+func (s *service) SayHelloURL(ctx context.Context, req *demo.HelloReq) (reply *demo.HelloResp, err error) {
+	name := req.Name
+	if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get("name")) > 0 {
+		name = md.Get("name")[0]
+	}
+
+	if name == "nobody" {
+		return nil, status.NothingFound
+	}
+	return &demo.HelloResp{Content: "Hello " + name}, nil
+}
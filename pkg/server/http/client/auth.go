@@ -0,0 +1,240 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/UnderTreeTech/waterdrop/pkg/server/http/metadata"
+	"github.com/UnderTreeTech/waterdrop/pkg/utils/xcrypto"
+	"github.com/UnderTreeTech/waterdrop/pkg/utils/xstring"
+	"github.com/UnderTreeTech/waterdrop/pkg/utils/xtime"
+
+	"github.com/go-resty/resty/v2"
+)
+
+const (
+	// defaultExpiryDelta default duration to refresh tokens ahead of their expiry
+	defaultExpiryDelta = 10 * time.Second
+	// defaultTokenTimeout default timeout of token requests
+	defaultTokenTimeout = 5 * time.Second
+)
+
+// Signature signs requests with appkey and secret
+// sign algorithm:hex(hmac_sha256(secret, query params + body + timestamp + nonce))
+// Query params of the final url are encoded to `"bar=baz&foo=quux"` sorted by key, and body is
+// the exact bytes on the wire, see metadata.SignContent.
+// Notice:requests are signed by a transport wrapper, which is called right before requests
+// are sent, so the returned middleware does nothing. Use only one of Signature and MD5Signature
+func Signature(client *Client) resty.RequestMiddleware {
+	wrapTransport(client, func(req *http.Request) error {
+		ts := strconv.Itoa(int(xtime.Now().CurrentUnixTime()))
+		nonce := xstring.RandomString(metadata.DefaultNonceLen)
+		content, err := metadata.SignContent(req)
+		if err != nil {
+			return err
+		}
+
+		sign, err := xcrypto.HmacSHA256ToString([]byte(client.config.Secret), content+ts+nonce, xcrypto.HEX)
+		if err != nil {
+			return err
+		}
+		req.Header.Set(metadata.HeaderSign, sign)
+		req.Header.Set(metadata.HeaderSignMethod, metadata.SignMethodHmacSHA256)
+		req.Header.Set(metadata.HeaderNonce, nonce)
+		req.Header.Set(metadata.HeaderTimestamp, ts)
+		return nil
+	})
+	return skip
+}
+
+// MD5Signature signs requests with the legacy md5 algorithm, use it only when peer
+// services are not able to verify Signature
+// sign algorithm:md5(query params + body + secret + timestamp + nonce)
+func MD5Signature(client *Client) resty.RequestMiddleware {
+	wrapTransport(client, func(req *http.Request) error {
+		ts := strconv.Itoa(int(xtime.Now().CurrentUnixTime()))
+		nonce := xstring.RandomString(metadata.DefaultNonceLen)
+		content, err := metadata.SignContent(req)
+		if err != nil {
+			return err
+		}
+
+		sign, err := xcrypto.HashToString(content+client.config.Secret+ts+nonce, xcrypto.MD5, xcrypto.HEX)
+		if err != nil {
+			return err
+		}
+		req.Header.Del(metadata.HeaderSignMethod)
+		req.Header.Set(metadata.HeaderSign, sign)
+		req.Header.Set(metadata.HeaderNonce, nonce)
+		req.Header.Set(metadata.HeaderTimestamp, ts)
+		return nil
+	})
+	return skip
+}
+
+// wrapTransport wraps transport of the client with the sign func
+func wrapTransport(client *Client, sign func(req *http.Request) error) {
+	transport := client.client.GetClient().Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	client.client.SetTransport(&signTransport{transport: transport, sign: sign})
+}
+
+// skip a RequestMiddleware does nothing
+func skip(cli *resty.Client, request *resty.Request) error {
+	return nil
+}
+
+// BearerToken returns a RequestMiddleware which attaches the static bearer token
+func BearerToken(token string) RequestMiddleware {
+	return func(client *Client) resty.RequestMiddleware {
+		return func(cli *resty.Client, request *resty.Request) error {
+			request.SetHeader(metadata.HeaderAuthorization, "Bearer "+token)
+			return nil
+		}
+	}
+}
+
+// OAuth2Config oauth2 client credentials grant configs
+type OAuth2Config struct {
+	// TokenURL token endpoint of the authorization server
+	TokenURL string
+	// ClientID client id
+	ClientID string
+	// ClientSecret client secret
+	ClientSecret string
+	// Scopes requested scopes
+	Scopes []string
+	// ExpiryDelta refresh tokens ahead of their expiry, default 10s
+	ExpiryDelta time.Duration
+	// Timeout token request timeout, default 5s
+	Timeout time.Duration
+}
+
+// OAuth2ClientCredentials returns a RequestMiddleware which attaches access tokens obtained
+// by oauth2 client credentials grant. Tokens are cached and refreshed ahead of their expiry,
+// and they're dropped once peer services reply 401 so the next request refreshes them.
+func OAuth2ClientCredentials(config *OAuth2Config) RequestMiddleware {
+	source := newTokenSource(config)
+	return func(client *Client) resty.RequestMiddleware {
+		client.client.OnAfterResponse(func(cli *resty.Client, resp *resty.Response) error {
+			if resp.StatusCode() == http.StatusUnauthorized {
+				source.reset()
+			}
+			return nil
+		})
+
+		return func(cli *resty.Client, request *resty.Request) error {
+			token, err := source.token(request.Context())
+			if err != nil {
+				return err
+			}
+			request.SetHeader(metadata.HeaderAuthorization, token)
+			return nil
+		}
+	}
+}
+
+// tokenReply token endpoint reply
+type tokenReply struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// tokenSource caches oauth2 access token
+type tokenSource struct {
+	config *OAuth2Config
+	client *resty.Client
+	mutex  sync.Mutex
+	value  string
+	expiry time.Time
+}
+
+// newTokenSource returns a tokenSource
+func newTokenSource(config *OAuth2Config) *tokenSource {
+	if config.ExpiryDelta <= 0 {
+		config.ExpiryDelta = defaultExpiryDelta
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaultTokenTimeout
+	}
+
+	return &tokenSource{
+		config: config,
+		client: resty.New().SetTimeout(config.Timeout),
+	}
+}
+
+// token returns the authorization header value, it requests a new token if the cached
+// one is about to expire. Concurrent requests wait for the same token request.
+func (s *tokenSource) token(ctx context.Context) (string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.value != "" && time.Now().Add(s.config.ExpiryDelta).Before(s.expiry) {
+		return s.value, nil
+	}
+
+	form := map[string]string{"grant_type": "client_credentials"}
+	if len(s.config.Scopes) > 0 {
+		form["scope"] = strings.Join(s.config.Scopes, " ")
+	}
+
+	reply := &tokenReply{}
+	resp, err := s.client.R().
+		SetContext(ctx).
+		SetBasicAuth(s.config.ClientID, s.config.ClientSecret).
+		SetFormData(form).
+		SetResult(reply).
+		Post(s.config.TokenURL)
+	if err != nil {
+		return "", err
+	}
+	if resp.IsError() || reply.AccessToken == "" {
+		return "", fmt.Errorf("request oauth2 token fail, status %s, reply %s", resp.Status(), resp.Body())
+	}
+
+	tokenType := reply.TokenType
+	if tokenType == "" || strings.EqualFold(tokenType, "bearer") {
+		tokenType = "Bearer"
+	}
+	s.value = tokenType + " " + reply.AccessToken
+	// tokens without expiry are cached until peer services reply 401
+	s.expiry = time.Now().Add(time.Duration(reply.ExpiresIn) * time.Second)
+	if reply.ExpiresIn <= 0 {
+		s.expiry = time.Now().Add(100 * 365 * 24 * time.Hour)
+	}
+	return s.value, nil
+}
+
+// reset drops the cached token
+func (s *tokenSource) reset() {
+	s.mutex.Lock()
+	s.value = ""
+	s.mutex.Unlock()
+}
@@ -0,0 +1,161 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/UnderTreeTech/waterdrop/pkg/log"
+	"github.com/UnderTreeTech/waterdrop/pkg/server/http/config"
+	"github.com/UnderTreeTech/waterdrop/pkg/server/http/metadata"
+	"github.com/UnderTreeTech/waterdrop/pkg/utils/xcrypto"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// echoAuthServer replies the authorization header, it replies 401 if the token is revoked
+func echoAuthServer(revoked *atomic.Value) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get(metadata.HeaderAuthorization)
+		if revoked != nil && revoked.Load() == auth {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(auth))
+	}))
+}
+
+func TestBearerToken(t *testing.T) {
+	defer log.New(nil).Sync()
+	srv := echoAuthServer(nil)
+	defer srv.Close()
+
+	client := New(&config.ClientConfig{HostURL: srv.URL})
+	client.Use(BearerToken("token"))
+	reply, err := client.RawGet(context.Background(), &Request{URI: "/"})
+	assert.Nil(t, err)
+	assert.Equal(t, "Bearer token", string(reply))
+}
+
+func TestOAuth2ClientCredentials(t *testing.T) {
+	defer log.New(nil).Sync()
+	var issued int32
+	tokenSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, secret, ok := r.BasicAuth()
+		if !ok || id != "id" || secret != "secret" || r.FormValue("grant_type") != "client_credentials" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		n := atomic.AddInt32(&issued, 1)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": fmt.Sprintf("token-%d-%s", n, r.FormValue("scope")),
+			"token_type":   "bearer",
+			"expires_in":   3600,
+		})
+	}))
+	defer tokenSrv.Close()
+
+	revoked := &atomic.Value{}
+	revoked.Store("")
+	srv := echoAuthServer(revoked)
+	defer srv.Close()
+
+	client := New(&config.ClientConfig{HostURL: srv.URL})
+	client.Use(OAuth2ClientCredentials(&OAuth2Config{
+		TokenURL:     tokenSrv.URL,
+		ClientID:     "id",
+		ClientSecret: "secret",
+		Scopes:       []string{"read", "write"},
+	}))
+
+	// tokens are cached
+	for i := 0; i < 3; i++ {
+		reply, err := client.RawGet(context.Background(), &Request{URI: "/"})
+		assert.Nil(t, err)
+		assert.Equal(t, "Bearer token-1-read write", string(reply))
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&issued))
+
+	// tokens are refreshed after 401
+	revoked.Store("Bearer token-1-read write")
+	_, _ = client.RawGet(context.Background(), &Request{URI: "/"})
+	reply, err := client.RawGet(context.Background(), &Request{URI: "/"})
+	assert.Nil(t, err)
+	assert.Equal(t, "Bearer token-2-read write", string(reply))
+
+	// tokens are refreshed ahead of expiry
+	client = New(&config.ClientConfig{HostURL: srv.URL})
+	client.Use(OAuth2ClientCredentials(&OAuth2Config{
+		TokenURL:     tokenSrv.URL,
+		ClientID:     "id",
+		ClientSecret: "secret",
+		ExpiryDelta:  3601e9,
+	}))
+	_, _ = client.RawGet(context.Background(), &Request{URI: "/"})
+	_, _ = client.RawGet(context.Background(), &Request{URI: "/"})
+	assert.Equal(t, int32(4), atomic.LoadInt32(&issued))
+
+	// token requests failure fails requests
+	client = New(&config.ClientConfig{HostURL: srv.URL})
+	client.Use(OAuth2ClientCredentials(&OAuth2Config{TokenURL: tokenSrv.URL, ClientID: "id"}))
+	_, err = client.RawGet(context.Background(), &Request{URI: "/"})
+	assert.NotNil(t, err)
+}
+
+func TestSignature(t *testing.T) {
+	defer log.New(nil).Sync()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, metadata.SignMethodHmacSHA256, r.Header.Get(metadata.HeaderSignMethod))
+		content := r.URL.Query().Encode() + `{"name":"waterdrop"}` + r.Header.Get(metadata.HeaderTimestamp) + r.Header.Get(metadata.HeaderNonce)
+		sign, _ := xcrypto.HmacSHA256ToString([]byte("secret"), content, xcrypto.HEX)
+		assert.Equal(t, sign, r.Header.Get(metadata.HeaderSign))
+	}))
+	defer srv.Close()
+
+	client := New(&config.ClientConfig{HostURL: srv.URL, Secret: "secret"})
+	client.Use(Signature)
+	_, err := client.RawPost(context.Background(), &Request{
+		URI:        "/?c=3",
+		QueryParam: map[string][]string{"b": {"2"}, "a": {"1"}},
+		Body:       map[string]string{"name": "waterdrop"},
+	})
+	assert.Nil(t, err)
+
+	// string body is signed as it is, rather than its json encoding
+	_, err = client.RawPost(context.Background(), &Request{URI: "/", Body: `{"name":"waterdrop"}`})
+	assert.Nil(t, err)
+
+	// pre-request hook of the resty client doesn't replace signing
+	var hooked bool
+	client.client.SetPreRequestHook(func(cli *resty.Client, req *http.Request) error {
+		hooked = true
+		return nil
+	})
+	_, err = client.RawPost(context.Background(), &Request{URI: "/", Body: `{"name":"waterdrop"}`})
+	assert.Nil(t, err)
+	assert.True(t, hooked)
+}
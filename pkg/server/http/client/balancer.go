@@ -0,0 +1,212 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package client
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/UnderTreeTech/waterdrop/pkg/log"
+	"github.com/UnderTreeTech/waterdrop/pkg/registry"
+	"github.com/UnderTreeTech/waterdrop/pkg/status"
+)
+
+const (
+	// schemeHTTP scheme of instances the client balances among
+	schemeHTTP = "http"
+	// defaultWeight weight of instance without weight metadata
+	defaultWeight = 10
+	// defaultEjectFailures default consecutive failures to eject an instance
+	defaultEjectFailures = 5
+	// defaultEjectDuration default duration an ejected instance stays out of balancing
+	defaultEjectDuration = 30 * time.Second
+)
+
+// instance a resolved peer service instance
+type instance struct {
+	// url instance base url, like http://127.0.0.1:8080
+	url           string
+	color         string
+	weight        int
+	currentWeight int
+	failures      int
+	ejectedUntil  time.Time
+}
+
+// balancer smooth weighted round robin balancer which ejects instances failed
+// consecutively, it's color aware, see registry.WithColor for details
+type balancer struct {
+	mutex         sync.Mutex
+	instances     []*instance
+	ejectFailures int
+	ejectDuration time.Duration
+}
+
+// newBalancer returns a balancer
+func newBalancer(ejectFailures int, ejectDuration time.Duration) *balancer {
+	if ejectFailures <= 0 {
+		ejectFailures = defaultEjectFailures
+	}
+	if ejectDuration <= 0 {
+		ejectDuration = defaultEjectDuration
+	}
+
+	return &balancer{
+		ejectFailures: ejectFailures,
+		ejectDuration: ejectDuration,
+	}
+}
+
+// update replaces instances with the http services, states of the instances
+// still alive are kept. Instances are cleared if no http service is resolved,
+// requests fail with service unavailable until any instance registers again.
+func (b *balancer) update(services []*registry.ServiceInfo) {
+	instances := make([]*instance, 0, len(services))
+	for _, service := range services {
+		if service.Scheme != schemeHTTP {
+			continue
+		}
+
+		url := service.Addr
+		if !strings.Contains(url, "://") {
+			url = schemeHTTP + "://" + url
+		}
+		instances = append(instances, &instance{
+			url:    strings.TrimSuffix(url, "/"),
+			color:  service.Metadata[registry.MetaColor],
+			weight: weight(service),
+		})
+	}
+
+	if len(instances) == 0 {
+		log.Warnf("zero http instance resolved", log.Any("services", services))
+	}
+
+	sort.Slice(instances, func(i, j int) bool {
+		return instances[i].url < instances[j].url
+	})
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	olds := make(map[string]*instance, len(b.instances))
+	for _, ins := range b.instances {
+		olds[ins.url] = ins
+	}
+	for _, ins := range instances {
+		if old, ok := olds[ins.url]; ok {
+			ins.currentWeight = old.currentWeight
+			ins.failures = old.failures
+			ins.ejectedUntil = old.ejectedUntil
+		}
+	}
+	b.instances = instances
+}
+
+// pick picks an instance of the color. Instances without color make up the default
+// group, requests without color or whose color group is unavailable go to it, and
+// if there is none of them, the default group falls back to all instances.
+// Ejected instances are skipped unless all instances of the group are ejected.
+func (b *balancer) pick(color string) (*instance, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if len(b.instances) == 0 {
+		return nil, status.ServiceUnavailable
+	}
+
+	var candidates []*instance
+	if color != "" {
+		candidates = b.group(color)
+	}
+	if len(candidates) == 0 {
+		candidates = b.group("")
+	}
+	if len(candidates) == 0 {
+		candidates = b.instances
+	}
+
+	now := time.Now()
+	available := make([]*instance, 0, len(candidates))
+	for _, ins := range candidates {
+		if now.After(ins.ejectedUntil) {
+			available = append(available, ins)
+		}
+	}
+	if len(available) == 0 {
+		available = candidates
+	}
+
+	var (
+		total int
+		best  *instance
+	)
+	for _, ins := range available {
+		ins.currentWeight += ins.weight
+		total += ins.weight
+		if best == nil || ins.currentWeight > best.currentWeight {
+			best = ins
+		}
+	}
+
+	best.currentWeight -= total
+	return best, nil
+}
+
+// group returns instances of the color
+func (b *balancer) group(color string) []*instance {
+	instances := make([]*instance, 0, len(b.instances))
+	for _, ins := range b.instances {
+		if ins.color == color {
+			instances = append(instances, ins)
+		}
+	}
+	return instances
+}
+
+// report reports the request result of the instance, the instance is ejected
+// after it fails consecutively EjectFailures times
+func (b *balancer) report(ins *instance, failed bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if !failed {
+		ins.failures = 0
+		return
+	}
+
+	ins.failures++
+	if ins.failures >= b.ejectFailures {
+		ins.failures = 0
+		ins.ejectedUntil = time.Now().Add(b.ejectDuration)
+		log.Warnf("eject http instance", log.String("peer", ins.url), log.Duration("duration", b.ejectDuration))
+	}
+}
+
+// weight returns the weight metadata of the service, it returns defaultWeight
+// if weight metadata is missing or invalid
+func weight(service *registry.ServiceInfo) int {
+	w, err := strconv.Atoi(service.Metadata[registry.MetaWeight])
+	if err != nil || w <= 0 {
+		return defaultWeight
+	}
+	return w
+}
@@ -0,0 +1,196 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/UnderTreeTech/waterdrop/pkg/log"
+	"github.com/UnderTreeTech/waterdrop/pkg/registry"
+	"github.com/UnderTreeTech/waterdrop/pkg/server/http/config"
+	"github.com/UnderTreeTech/waterdrop/pkg/status"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mockRegistry in memory registry which notifies watchers on changes
+type mockRegistry struct {
+	mutex    sync.Mutex
+	services []*registry.ServiceInfo
+	events   chan struct{}
+}
+
+func newMockRegistry(services ...*registry.ServiceInfo) *mockRegistry {
+	return &mockRegistry{services: services, events: make(chan struct{}, 1)}
+}
+
+func (m *mockRegistry) Register(ctx context.Context, info *registry.ServiceInfo) error {
+	m.mutex.Lock()
+	m.services = append(m.services, info)
+	m.mutex.Unlock()
+	m.events <- struct{}{}
+	return nil
+}
+
+func (m *mockRegistry) DeRegister(ctx context.Context, info *registry.ServiceInfo) error {
+	m.mutex.Lock()
+	services := make([]*registry.ServiceInfo, 0, len(m.services))
+	for _, service := range m.services {
+		if service.Addr != info.Addr {
+			services = append(services, service)
+		}
+	}
+	m.services = services
+	m.mutex.Unlock()
+	m.events <- struct{}{}
+	return nil
+}
+
+func (m *mockRegistry) List(ctx context.Context, name string, scheme string) ([]*registry.ServiceInfo, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return append([]*registry.ServiceInfo(nil), m.services...), nil
+}
+
+func (m *mockRegistry) Watch(ctx context.Context, name string) (<-chan struct{}, error) {
+	return m.events, nil
+}
+
+func (m *mockRegistry) Close() {}
+
+// service returns a http service info
+func service(addr string, metadata map[string]string) *registry.ServiceInfo {
+	return &registry.ServiceInfo{Name: "demo", Scheme: "http", Addr: addr, Metadata: metadata}
+}
+
+func TestBalancerWeight(t *testing.T) {
+	defer log.New(nil).Sync()
+	b := newBalancer(0, 0)
+	b.update([]*registry.ServiceInfo{
+		service("http://127.0.0.1:8001", map[string]string{registry.MetaWeight: "3"}),
+		service("http://127.0.0.1:8002", map[string]string{registry.MetaWeight: "1"}),
+		{Name: "demo", Scheme: "grpc", Addr: "grpc://127.0.0.1:9000"},
+	})
+
+	counts := make(map[string]int)
+	for i := 0; i < 8; i++ {
+		ins, err := b.pick("")
+		assert.Nil(t, err)
+		counts[ins.url]++
+	}
+	assert.Equal(t, 6, counts["http://127.0.0.1:8001"])
+	assert.Equal(t, 2, counts["http://127.0.0.1:8002"])
+}
+
+func TestBalancerColor(t *testing.T) {
+	defer log.New(nil).Sync()
+	b := newBalancer(0, 0)
+	b.update([]*registry.ServiceInfo{
+		service("127.0.0.1:8001", nil),
+		service("127.0.0.1:8002", map[string]string{registry.MetaColor: "canary"}),
+	})
+
+	for i := 0; i < 4; i++ {
+		ins, _ := b.pick("canary")
+		assert.Equal(t, "http://127.0.0.1:8002", ins.url)
+		ins, _ = b.pick("")
+		assert.Equal(t, "http://127.0.0.1:8001", ins.url)
+		ins, _ = b.pick("blue")
+		assert.Equal(t, "http://127.0.0.1:8001", ins.url)
+	}
+}
+
+func TestBalancerEject(t *testing.T) {
+	defer log.New(nil).Sync()
+	b := newBalancer(2, 50*time.Millisecond)
+	_, err := b.pick("")
+	assert.Equal(t, status.ServiceUnavailable.Code(), status.ExtractStatus(err).Code())
+
+	b.update([]*registry.ServiceInfo{
+		service("http://127.0.0.1:8001", nil),
+		service("http://127.0.0.1:8002", nil),
+	})
+	bad := b.instances[0]
+	b.report(bad, true)
+	b.report(bad, false)
+	b.report(bad, true)
+	assert.Equal(t, 1, bad.failures)
+	b.report(bad, true)
+
+	for i := 0; i < 4; i++ {
+		ins, _ := b.pick("")
+		assert.Equal(t, "http://127.0.0.1:8002", ins.url)
+	}
+
+	// ejection states are kept through updates
+	b.update([]*registry.ServiceInfo{
+		service("http://127.0.0.1:8001", nil),
+		service("http://127.0.0.1:8002", nil),
+	})
+	ins, _ := b.pick("")
+	assert.Equal(t, "http://127.0.0.1:8002", ins.url)
+
+	time.Sleep(60 * time.Millisecond)
+	urls := make(map[string]bool)
+	for i := 0; i < 2; i++ {
+		ins, _ := b.pick("")
+		urls[ins.url] = true
+	}
+	assert.Len(t, urls, 2)
+}
+
+func TestNewWithRegistry(t *testing.T) {
+	defer log.New(nil).Sync()
+	handler := func(name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(name))
+		}
+	}
+	srv1 := httptest.NewServer(handler("srv1"))
+	defer srv1.Close()
+	srv2 := httptest.NewServer(handler("srv2"))
+	defer srv2.Close()
+
+	reg := newMockRegistry(service(srv1.URL, nil))
+	client := NewWithRegistry(&config.ClientConfig{Service: "demo"}, reg)
+	defer client.Close()
+
+	reply, err := client.RawGet(context.Background(), &Request{URI: "/ping"})
+	assert.Nil(t, err)
+	assert.Equal(t, "srv1", string(reply))
+
+	assert.Nil(t, reg.Register(context.Background(), service(srv2.URL, nil)))
+	assert.Nil(t, reg.DeRegister(context.Background(), service(srv1.URL, nil)))
+	assert.Eventually(t, func() bool {
+		reply, err := client.RawGet(context.Background(), &Request{URI: "/ping"})
+		return err == nil && string(reply) == "srv2"
+	}, time.Second, 10*time.Millisecond)
+
+	// instances are cleared once all of them deregister
+	assert.Nil(t, reg.DeRegister(context.Background(), service(srv2.URL, nil)))
+	assert.Eventually(t, func() bool {
+		_, err := client.RawGet(context.Background(), &Request{URI: "/ping"})
+		return err == status.ServiceUnavailable
+	}, time.Second, 10*time.Millisecond)
+}
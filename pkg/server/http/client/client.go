@@ -20,7 +20,9 @@ package client
 
 import (
 	"context"
-	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -29,18 +31,17 @@ import (
 
 	"github.com/UnderTreeTech/waterdrop/pkg/server/http/metadata"
 
-	"github.com/UnderTreeTech/waterdrop/pkg/utils/xcrypto"
-
 	"github.com/UnderTreeTech/waterdrop/pkg/server/http/config"
 
 	"github.com/UnderTreeTech/waterdrop/pkg/breaker"
+	baggage "github.com/UnderTreeTech/waterdrop/pkg/metadata"
+	"github.com/UnderTreeTech/waterdrop/pkg/registry"
 
+	"github.com/UnderTreeTech/waterdrop/pkg/stats/metric"
 	"github.com/UnderTreeTech/waterdrop/pkg/status"
 	"github.com/UnderTreeTech/waterdrop/pkg/trace"
 	"github.com/opentracing/opentracing-go/ext"
 
-	"github.com/UnderTreeTech/waterdrop/pkg/utils/xtime"
-
 	"github.com/UnderTreeTech/waterdrop/pkg/log"
 	"github.com/UnderTreeTech/waterdrop/pkg/utils/xstring"
 	tlog "github.com/opentracing/opentracing-go/log"
@@ -48,6 +49,15 @@ import (
 	"github.com/go-resty/resty/v2"
 )
 
+const (
+	// defaultRetryBackoff default backoff before the first retry
+	defaultRetryBackoff = 100 * time.Millisecond
+	// defaultMaxRetryBackoff default max backoff between retries
+	defaultMaxRetryBackoff = 2 * time.Second
+	// defaultMaxLogReplySize default max reply bytes in the access log
+	defaultMaxLogReplySize = 1024
+)
+
 // Request request params
 type Request struct {
 	URI        string
@@ -56,6 +66,11 @@ type Request struct {
 	PathParam  map[string]string
 	Headers    map[string]string
 	Error      interface{}
+	// FormData multipart form fields, used by Upload
+	FormData map[string]string
+	// Files multipart files keyed by form field name, valued by file path, used by Upload.
+	// Files are opened every time the request is sent, so uploads can be retried
+	Files map[string]string
 }
 
 // RequestMiddleware http request middleware
@@ -66,6 +81,8 @@ type Client struct {
 	client   *resty.Client
 	config   *config.ClientConfig
 	breakers *breaker.BreakerGroup
+	balancer *balancer
+	cancel   context.CancelFunc
 }
 
 // New return a http client
@@ -74,6 +91,9 @@ func New(config *config.ClientConfig) *Client {
 	cli.SetTimeout(config.Timeout)
 	cli.SetDebug(config.EnableDebug)
 	cli.SetBaseURL(config.HostURL)
+	if config.MaxResponseSize > 0 {
+		cli.SetTransport(&limitTransport{transport: cli.GetClient().Transport, limit: config.MaxResponseSize})
+	}
 
 	return &Client{
 		client:   cli,
@@ -82,6 +102,53 @@ func New(config *config.ClientConfig) *Client {
 	}
 }
 
+// NewWithRegistry return a http client which resolves instances of config.Service
+// through the registry, and balances requests among the instances with scheme http.
+// Instance changes are watched if the registry implements registry.Watcher.
+func NewWithRegistry(config *config.ClientConfig, reg registry.Registry) *Client {
+	cli := New(config)
+	cli.balancer = newBalancer(config.EjectFailures, config.EjectDuration)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cli.cancel = cancel
+	services, err := reg.List(ctx, config.Service, schemeHTTP)
+	if err != nil {
+		cancel()
+		panic(fmt.Sprintf("resolve peer service fail, service %s, error %s", config.Service, err.Error()))
+	}
+	cli.balancer.update(services)
+
+	watcher, ok := reg.(registry.Watcher)
+	if !ok {
+		return cli
+	}
+
+	events, err := watcher.Watch(ctx, config.Service)
+	if err != nil {
+		cancel()
+		panic(fmt.Sprintf("watch peer service fail, service %s, error %s", config.Service, err.Error()))
+	}
+
+	go func() {
+		for range events {
+			services, err := reg.List(ctx, config.Service, schemeHTTP)
+			if err != nil {
+				continue
+			}
+			cli.balancer.update(services)
+		}
+	}()
+
+	return cli
+}
+
+// Close stop watching instance changes of the peer service
+func (c *Client) Close() {
+	if c.cancel != nil {
+		c.cancel()
+	}
+}
+
 // Use set client request middleware
 func (c *Client) Use(m RequestMiddleware) *Client {
 	rm := m(c)
@@ -103,7 +170,7 @@ func (c *Client) newRequest(method string, req *Request, reply interface{}) *res
 	if req.Error != nil {
 		request.SetError(req.Error)
 	}
-	if method != http.MethodGet {
+	if method != http.MethodGet && method != http.MethodHead {
 		request.SetHeader(metadata.HeaderContentType, metadata.DefaultContentTypeJson)
 	}
 
@@ -114,9 +181,55 @@ func (c *Client) newRequest(method string, req *Request, reply interface{}) *res
 	return request
 }
 
-// execute send http request
+// execute send http request. Idempotent requests and POST requests are retried on
+// network errors and 5xx if MaxRetries is set, POST requests carry an Idempotency-Key
+// header, which is kept the same among retries, for servers to deduplicate them.
 func (c *Client) execute(ctx context.Context, request *resty.Request) (reply *resty.Response, err error) {
-	err = c.breakers.Do(request.URL,
+	uri := request.URL
+	retries := 0
+	if c.config.MaxRetries > 0 && canRetry(request.Method) {
+		retries = c.config.MaxRetries
+		if request.Method == http.MethodPost && request.Header.Get(metadata.HeaderIdempotencyKey) == "" {
+			request.SetHeader(metadata.HeaderIdempotencyKey, xstring.GenerateUUID())
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		var retryable bool
+		reply, retryable, err = c.do(ctx, request, uri)
+		if !retryable || attempt >= retries || ctx.Err() != nil {
+			return
+		}
+
+		// release the connection of streaming response before retrying
+		if reply != nil && reply.RawBody() != nil {
+			reply.RawBody().Close()
+		}
+
+		timer := time.NewTimer(c.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// do send http request once, retryable reports whether the request failed
+// on network errors or 5xx
+func (c *Client) do(ctx context.Context, request *resty.Request, uri string) (reply *resty.Response, retryable bool, err error) {
+	// breakers are kept per url, or per instance if instances are resolved through the registry
+	peer, key := c.client.HostURL, uri
+	var ins *instance
+	if c.balancer != nil {
+		if ins, err = c.balancer.pick(registry.ColorFromContext(ctx)); err != nil {
+			return
+		}
+		peer, key = ins.url, ins.url
+	}
+
+	err = c.breakers.Do(key,
 		func() error {
 			// adjust request timeout
 			timeout := c.config.Timeout
@@ -128,12 +241,16 @@ func (c *Client) execute(ctx context.Context, request *resty.Request) (reply *re
 			}
 
 			request.SetHeader(metadata.HeaderHttpTimeout, strconv.Itoa(int(timeout.Milliseconds())))
-			span, sctx := trace.StartSpanFromContext(ctx, request.Method+" "+request.URL)
+			if color := registry.ColorFromContext(ctx); color != "" {
+				request.SetHeader(registry.ColorHeader, color)
+			}
+			baggage.InjectHeader(ctx, request.Header)
+			span, sctx := trace.StartSpanFromContext(ctx, request.Method+" "+uri)
 			sctx = trace.HeaderInjector(sctx, request.Header)
 			ext.Component.Set(span, "http")
 			ext.SpanKind.Set(span, ext.SpanKindRPCClientEnum)
 			ext.HTTPMethod.Set(span, request.Method)
-			ext.HTTPUrl.Set(span, request.URL)
+			ext.HTTPUrl.Set(span, peer+uri)
 			request.SetContext(sctx)
 			// zero timeout config means never timeout
 			var cancel func()
@@ -153,14 +270,27 @@ func (c *Client) execute(ctx context.Context, request *resty.Request) (reply *re
 				quota = time.Until(deadline).Seconds()
 			}
 			var rerr error
-			reply, rerr = request.Execute(request.Method, request.URL)
+			target := uri
+			if ins != nil {
+				target = peer + uri
+			}
+			reply, rerr = request.Execute(request.Method, target)
 			estatus := status.OK
 			if rerr != nil {
+				// only network errors are retried, errors of request middlewares are not
 				if uerr, ok := rerr.(*url.Error); ok {
 					rerr = uerr.Unwrap()
+					retryable = rerr != ErrResponseTooLarge
 				}
 				estatus = status.ExtractContextStatus(rerr)
 			}
+			// request middlewares fail before the request is sent
+			if reply == nil {
+				reply = &resty.Response{Request: request}
+			}
+			if reply.StatusCode() >= http.StatusInternalServerError {
+				retryable = true
+			}
 
 			if estatus.Code() != status.OK.Code() {
 				ext.Error.Set(span, true)
@@ -168,18 +298,27 @@ func (c *Client) execute(ctx context.Context, request *resty.Request) (reply *re
 			}
 
 			duration := time.Since(now)
+			// code is the http status code, or error code if no response received
+			code := estatus.Error()
+			if reply.StatusCode() > 0 {
+				code = strconv.Itoa(reply.StatusCode())
+			}
+			host, route := metricLabels(peer, uri)
+			metric.HTTPClientHandleCounter.Inc(host, request.Method, route, code)
+			metric.HTTPClientReqDuration.Observe(duration.Seconds(), host, request.Method, route)
+
 			fields := make([]log.Field, 0, 12)
 			fields = append(
 				fields,
-				log.String("peer", c.client.HostURL),
+				log.String("peer", peer),
 				log.String("method", request.Method),
-				log.String("path", strings.TrimPrefix(request.URL, c.client.HostURL)),
+				log.String("path", uri),
 				log.Any("headers", request.Header),
 				log.String("query", request.QueryParam.Encode()),
 				log.Any("body", request.Body),
 				log.Float64("quota", quota),
 				log.Float64("duration", duration.Seconds()),
-				log.Bytes("reply", reply.Body()),
+				log.Bytes("reply", truncate(reply.Body(), c.config.MaxLogReplySize)),
 				log.Int("status", reply.StatusCode()),
 				log.Int("code", estatus.Code()),
 				log.String("error", estatus.Message()),
@@ -197,9 +336,77 @@ func (c *Client) execute(ctx context.Context, request *resty.Request) (reply *re
 			return estatus
 		},
 		accept)
+
+	if ins != nil {
+		c.balancer.report(ins, retryable || !accept(err))
+	}
 	return
 }
 
+// backoff returns the duration to wait before the next retry, it grows
+// exponentially from RetryBackoff to MaxRetryBackoff with jitter
+func (c *Client) backoff(attempt int) time.Duration {
+	base, max := c.config.RetryBackoff, c.config.MaxRetryBackoff
+	if base <= 0 {
+		base = defaultRetryBackoff
+	}
+	if max <= 0 {
+		max = defaultMaxRetryBackoff
+	}
+
+	backoff := max
+	if attempt < 30 && base<<uint(attempt) < max {
+		backoff = base << uint(attempt)
+	}
+	// random in [backoff/2, backoff)
+	return backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+}
+
+// metricLabels returns host and route labels of request metrics. Route is the uri
+// without query, keep path params in uri like /users/{id} and set them by
+// Request.PathParam, otherwise every path makes a new route
+func metricLabels(peer string, uri string) (host string, route string) {
+	route = uri
+	if idx := strings.IndexByte(route, '?'); idx >= 0 {
+		route = route[:idx]
+	}
+
+	if peer == "" {
+		peer = uri
+	}
+	if u, err := url.Parse(peer); err == nil {
+		host = u.Host
+		if peer == uri {
+			route = u.Path
+		}
+	}
+	return
+}
+
+// canRetry reports whether requests of the method can be retried, they are the
+// idempotent methods and POST, which is made idempotent by Idempotency-Key
+func canRetry(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete,
+		http.MethodOptions, http.MethodPost:
+		return true
+	default:
+		return false
+	}
+}
+
+// truncate truncates reply to size bytes for logging, size zero means defaultMaxLogReplySize
+// and negative size means never truncate
+func truncate(reply []byte, size int) []byte {
+	if size == 0 {
+		size = defaultMaxLogReplySize
+	}
+	if size < 0 || len(reply) <= size {
+		return reply
+	}
+	return reply[:size]
+}
+
 // accept calculate request success/failure ratio
 func accept(err error) bool {
 	if err != nil {
@@ -247,66 +454,95 @@ func (c *Client) Delete(ctx context.Context, req *Request, reply interface{}) (e
 	return
 }
 
+// Patch http patch request, it's never retried since PATCH is not idempotent
+// Notice that Patch only applied to JSON and XML response MIME type
+func (c *Client) Patch(ctx context.Context, req *Request, reply interface{}) (err error) {
+	request := c.newRequest(http.MethodPatch, req, reply)
+	_, err = c.execute(ctx, request)
+	return
+}
+
+// Head http head request and return response headers
+func (c *Client) Head(ctx context.Context, req *Request) (header http.Header, err error) {
+	request := c.newRequest(http.MethodHead, req, nil)
+	resp, err := c.execute(ctx, request)
+	if resp != nil {
+		header = resp.Header()
+	}
+	return
+}
+
+// Upload http multipart post request with req.FormData and req.Files
+// Notice that Upload only applied to JSON and XML response MIME type
+func (c *Client) Upload(ctx context.Context, req *Request, reply interface{}) (err error) {
+	request := c.newRequest(http.MethodPost, req, reply)
+	request.SetFormData(req.FormData)
+	request.SetFiles(req.Files)
+	_, err = c.execute(ctx, request)
+	return
+}
+
+// Download http get request and stream response body to w, it returns the number of
+// bytes written. Unlike other methods, 4xx and 5xx responses are returned as errors,
+// and the download must be done within the request timeout.
+func (c *Client) Download(ctx context.Context, req *Request, w io.Writer) (written int64, err error) {
+	request := c.newRequest(http.MethodGet, req, nil)
+	request.SetDoNotParseResponse(true)
+	resp, err := c.execute(ctx, request)
+	if resp == nil || resp.RawBody() == nil {
+		return
+	}
+	defer resp.RawBody().Close()
+
+	if err != nil {
+		return
+	}
+	if resp.IsError() {
+		return 0, fmt.Errorf("download %s fail, status %s", req.URI, resp.Status())
+	}
+	return io.Copy(w, resp.RawBody())
+}
+
 // RawGet http get request and return response body raw byte
 func (c *Client) RawGet(ctx context.Context, req *Request) (reply []byte, err error) {
 	request := c.newRequest(http.MethodGet, req, nil)
 	resp, err := c.execute(ctx, request)
-	return resp.Body(), err
+	return body(resp), err
 }
 
 // RawPost http post request and return response body raw byte
 func (c *Client) RawPost(ctx context.Context, req *Request) (reply []byte, err error) {
 	request := c.newRequest(http.MethodPost, req, nil)
 	resp, err := c.execute(ctx, request)
-	return resp.Body(), err
+	return body(resp), err
 }
 
 // RawPut http put request and return response body raw byte
 func (c *Client) RawPut(ctx context.Context, req *Request) (reply []byte, err error) {
 	request := c.newRequest(http.MethodPut, req, nil)
 	resp, err := c.execute(ctx, request)
-	return resp.Body(), err
+	return body(resp), err
+}
+
+// RawPatch http patch request and return response body raw byte
+func (c *Client) RawPatch(ctx context.Context, req *Request) (reply []byte, err error) {
+	request := c.newRequest(http.MethodPatch, req, nil)
+	resp, err := c.execute(ctx, request)
+	return body(resp), err
 }
 
 // RawDelete http delete request and return response body raw byte
 func (c *Client) RawDelete(ctx context.Context, req *Request) (reply []byte, err error) {
 	request := c.newRequest(http.MethodDelete, req, nil)
 	resp, err := c.execute(ctx, request)
-	return resp.Body(), err
+	return body(resp), err
 }
 
-// Signature a example of RequestMiddleware
-// sign algorithm:md5(query params + body + secret + timestamp + nonce)
-// Notice:stuff body only when HTTP METHOD is not GET.
-// Encode query params to `"bar=baz&foo=quux"` sorted by key in any case
-func Signature(client *Client) resty.RequestMiddleware {
-	return func(cli *resty.Client, request *resty.Request) error {
-		ts := strconv.Itoa(int(xtime.Now().CurrentUnixTime()))
-		nonce := xstring.RandomString(metadata.DefaultNonceLen)
-		sb := strings.Builder{}
-		query := request.QueryParam.Encode()
-		bodyStr := ""
-		if request.Method != http.MethodGet {
-			jsonReq, err := json.Marshal(request.Body)
-			if err != nil {
-				return err
-			}
-			bodyStr = xstring.BytesToString(jsonReq)
-		}
-
-		sb.WriteString(query)
-		sb.WriteString(bodyStr)
-		sb.WriteString(client.config.Secret)
-		sb.WriteString(ts)
-		sb.WriteString(nonce)
-		signStr := sb.String()
-		sign, err := xcrypto.HashToString(signStr, xcrypto.MD5, xcrypto.HEX)
-		if err != nil {
-			return err
-		}
-		request.SetHeader(metadata.HeaderSign, sign)
-		request.SetHeader(metadata.HeaderNonce, nonce)
-		request.SetHeader(metadata.HeaderTimestamp, ts)
+// body returns the response body, it's nil if the request is not sent,
+// e.g. rejected by breaker or no instance available
+func body(resp *resty.Response) []byte {
+	if resp == nil {
 		return nil
 	}
+	return resp.Body()
 }
@@ -19,17 +19,24 @@
 package client
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/UnderTreeTech/waterdrop/pkg/server/http/metadata"
 
 	"github.com/UnderTreeTech/waterdrop/pkg/log"
 
+	"github.com/UnderTreeTech/waterdrop/pkg/stats/metric"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/UnderTreeTech/waterdrop/pkg/server/http/config"
@@ -64,6 +71,11 @@ func TestHttp(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, string(rdel), "rawdelete")
 
+	// breakers are kept per url
+	stats := client.breakers.Stats()
+	assert.Contains(t, stats, "/rawget")
+	assert.Contains(t, stats, "/rawdelete")
+
 	get := &result{}
 	err = client.Get(context.Background(), &Request{URI: "/get"}, get)
 	assert.Nil(t, err)
@@ -141,3 +153,177 @@ func createTestServer(t *testing.T) *httptest.Server {
 
 	return ts
 }
+
+// TestRetry test retries on 5xx and idempotency key of POST requests
+func TestRetry(t *testing.T) {
+	defer log.New(nil).Sync()
+	var (
+		attempts int
+		keys     = make(map[string]bool)
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		keys[r.Header.Get(metadata.HeaderIdempotencyKey)] = true
+		if attempts%3 != 0 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte(r.Method))
+	}))
+	defer srv.Close()
+
+	client := New(&config.ClientConfig{
+		HostURL:      srv.URL,
+		MaxRetries:   2,
+		RetryBackoff: time.Millisecond,
+	})
+
+	reply, err := client.RawPost(context.Background(), &Request{URI: "/retry"})
+	assert.Nil(t, err)
+	assert.Equal(t, "POST", string(reply))
+	assert.Equal(t, 3, attempts)
+	assert.Len(t, keys, 1)
+	assert.False(t, keys[""])
+
+	// GET requests carry no idempotency key
+	attempts, keys = 0, make(map[string]bool)
+	reply, err = client.RawGet(context.Background(), &Request{URI: "/retry"})
+	assert.Nil(t, err)
+	assert.Equal(t, "GET", string(reply))
+	assert.Equal(t, 3, attempts)
+	assert.True(t, keys[""])
+
+	// PATCH requests are never retried
+	attempts = 0
+	_, err = client.RawPatch(context.Background(), &Request{URI: "/retry"})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, attempts)
+}
+
+// TestBackoff test retry backoff grows exponentially and is capped
+func TestBackoff(t *testing.T) {
+	client := New(&config.ClientConfig{RetryBackoff: 100 * time.Millisecond, MaxRetryBackoff: time.Second})
+	for attempt, max := range []time.Duration{100, 200, 400, 800, 1000, 1000} {
+		backoff := client.backoff(attempt)
+		assert.True(t, backoff >= max*time.Millisecond/2)
+		assert.True(t, backoff <= max*time.Millisecond)
+	}
+	assert.True(t, client.backoff(64) <= time.Second)
+}
+
+// TestMethods test http PATCH, HEAD, multipart upload and streaming download
+func TestMethods(t *testing.T) {
+	defer log.New(nil).Sync()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/patch":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"method":"patch"}`))
+		case "/head":
+			w.Header().Set("X-Method", r.Method)
+		case "/upload":
+			file, header, err := r.FormFile("file")
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			content, _ := ioutil.ReadAll(file)
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = fmt.Fprintf(w, `{"method":"%s:%s:%s"}`, r.FormValue("name"), header.Filename, content)
+		case "/download":
+			_, _ = w.Write(bytes.Repeat([]byte("a"), 4096))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	client := New(&config.ClientConfig{HostURL: srv.URL})
+
+	patch := &result{}
+	assert.Nil(t, client.Patch(context.Background(), &Request{URI: "/patch"}, patch))
+	assert.Equal(t, "patch", patch.Method)
+
+	header, err := client.Head(context.Background(), &Request{URI: "/head"})
+	assert.Nil(t, err)
+	assert.Equal(t, http.MethodHead, header.Get("X-Method"))
+
+	file := filepath.Join(t.TempDir(), "waterdrop.txt")
+	assert.Nil(t, ioutil.WriteFile(file, []byte("content"), 0600))
+	upload := &result{}
+	request := &Request{
+		URI:      "/upload",
+		FormData: map[string]string{"name": "waterdrop"},
+		Files:    map[string]string{"file": file},
+	}
+	assert.Nil(t, client.Upload(context.Background(), request, upload))
+	assert.Equal(t, "waterdrop:waterdrop.txt:content", upload.Method)
+
+	raw, err := client.RawGet(context.Background(), &Request{URI: "/download"})
+	assert.Nil(t, err)
+	assert.Len(t, raw, 4096)
+
+	buf := &bytes.Buffer{}
+	written, err := client.Download(context.Background(), &Request{URI: "/download"}, buf)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(4096), written)
+	assert.Equal(t, bytes.Repeat([]byte("a"), 4096), buf.Bytes())
+
+	_, err = client.Download(context.Background(), &Request{URI: "/missing"}, buf)
+	assert.NotNil(t, err)
+}
+
+// TestResponseSize test response size limits
+func TestResponseSize(t *testing.T) {
+	defer log.New(nil).Sync()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/chunked" {
+			_, _ = w.Write(bytes.Repeat([]byte("a"), 512))
+			w.(http.Flusher).Flush()
+			_, _ = w.Write(bytes.Repeat([]byte("a"), 512))
+			return
+		}
+		_, _ = w.Write(bytes.Repeat([]byte("a"), 1024))
+	}))
+	defer srv.Close()
+
+	client := New(&config.ClientConfig{HostURL: srv.URL, MaxResponseSize: 1024})
+	reply, err := client.RawGet(context.Background(), &Request{URI: "/"})
+	assert.Nil(t, err)
+	assert.Len(t, reply, 1024)
+
+	client = New(&config.ClientConfig{HostURL: srv.URL, MaxResponseSize: 1000, MaxRetries: 2})
+	_, err = client.RawGet(context.Background(), &Request{URI: "/"})
+	assert.NotNil(t, err)
+	_, err = client.RawGet(context.Background(), &Request{URI: "/chunked"})
+	assert.NotNil(t, err)
+	_, err = client.Download(context.Background(), &Request{URI: "/chunked"}, ioutil.Discard)
+	assert.Equal(t, ErrResponseTooLarge, err)
+}
+
+// TestTruncate test truncating reply in the access log
+func TestTruncate(t *testing.T) {
+	reply := bytes.Repeat([]byte("a"), 2048)
+	assert.Len(t, truncate(reply, 0), defaultMaxLogReplySize)
+	assert.Len(t, truncate(reply, 10), 10)
+	assert.Len(t, truncate(reply, -1), 2048)
+	assert.Len(t, truncate(reply[:5], 10), 5)
+}
+
+// TestMetric test request metrics labelled by route template
+func TestMetric(t *testing.T) {
+	defer log.New(nil).Sync()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	client := New(&config.ClientConfig{HostURL: srv.URL})
+	for _, id := range []string{"1", "2"} {
+		_, err := client.RawGet(context.Background(), &Request{URI: "/users/{id}?a=b", PathParam: map[string]string{"id": id}})
+		assert.Nil(t, err)
+	}
+
+	host := strings.TrimPrefix(srv.URL, "http://")
+	assert.Equal(t, float64(2), testutil.ToFloat64(metric.HTTPClientHandleCounter.WithLabelValues(host, http.MethodGet, "/users/{id}", "404")))
+}
@@ -0,0 +1,93 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package client
+
+import (
+	"errors"
+	"io"
+	"net/http"
+)
+
+// ErrResponseTooLarge returned when response body exceeds MaxResponseSize
+var ErrResponseTooLarge = errors.New("http: response body too large")
+
+// limitTransport limits the size of response body
+type limitTransport struct {
+	transport http.RoundTripper
+	limit     int64
+}
+
+// RoundTrip rejects responses whose Content-Length exceeds the limit, and fails
+// reading body with ErrResponseTooLarge once the limit is exceeded
+func (t *limitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.ContentLength > t.limit {
+		resp.Body.Close()
+		return nil, ErrResponseTooLarge
+	}
+
+	resp.Body = &limitedBody{ReadCloser: resp.Body, remaining: t.limit}
+	return resp, nil
+}
+
+// limitedBody response body which fails reading once the limit is exceeded
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+}
+
+// Read reads at most remaining bytes, it reads one more byte to detect
+// whether the body exceeds the limit
+func (b *limitedBody) Read(p []byte) (n int, err error) {
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+
+	n, err = b.ReadCloser.Read(p)
+	if int64(n) > b.remaining {
+		n = int(b.remaining)
+		b.remaining = 0
+		return n, ErrResponseTooLarge
+	}
+	b.remaining -= int64(n)
+	return
+}
+
+// signTransport signs requests right before they're sent, so the signature covers
+// the final url and the exact body bytes on the wire
+type signTransport struct {
+	transport http.RoundTripper
+	sign      func(req *http.Request) error
+}
+
+// RoundTrip signs a clone of the request and sends it
+func (t *signTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	clone := req.Clone(req.Context())
+	if err := t.sign(clone); err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+	return t.transport.RoundTrip(clone)
+}
@@ -38,6 +38,27 @@ type ClientConfig struct {
 	Key string
 	// Secret signature secret
 	Secret string
+
+	// Service peer service name, the client resolves instances of it through registry
+	// and balances requests among them instead of HostURL, see client.NewWithRegistry
+	Service string
+	// EjectFailures eject an instance after it fails consecutively so many times, default 5
+	EjectFailures int
+	// EjectDuration how long an ejected instance stays out of balancing, default 30s
+	EjectDuration time.Duration
+
+	// MaxRetries max retries of idempotent and POST requests on network errors and 5xx,
+	// zero means never retry
+	MaxRetries int
+	// RetryBackoff backoff before the first retry, it doubles on every retry, default 100ms
+	RetryBackoff time.Duration
+	// MaxRetryBackoff max backoff between retries, default 2s
+	MaxRetryBackoff time.Duration
+	// MaxResponseSize max bytes of response body, zero means unlimited
+	MaxResponseSize int64
+	// MaxLogReplySize reply bodies larger than it are truncated in the access log,
+	// zero means 1KB and negative means never truncate
+	MaxLogReplySize int
 }
 
 // ServerConfig http server config
@@ -52,6 +73,26 @@ type ServerConfig struct {
 	SlowRequestDuration time.Duration
 	// WatchConfig whether watch config file changes
 	WatchConfig bool
+
+	// ReadHeaderTimeout timeout of reading request headers
+	ReadHeaderTimeout time.Duration
+	// ReadTimeout timeout of reading entire request, including body
+	ReadTimeout time.Duration
+	// WriteTimeout timeout of writing response, keep it zero if websocket or server-sent events are served
+	WriteTimeout time.Duration
+	// IdleTimeout max time to wait for the next request of keep-alive connections
+	IdleTimeout time.Duration
+	// MaxHeaderBytes max bytes of request headers, zero means 1MB
+	MaxHeaderBytes int
+
+	// CertFile tls certificate file, server serves https if CertFile and KeyFile are set
+	CertFile string
+	// KeyFile tls private key file
+	KeyFile string
+	// ClientCAFile ca certificates file of clients, client certificates are required and verified if it's set
+	ClientCAFile string
+	// H2C whether serve cleartext HTTP/2 without tls
+	H2C bool
 }
 
 // DefaultServerConfig default server configs, for start http server out of box
@@ -61,5 +102,7 @@ func DefaultServerConfig() *ServerConfig {
 		Mode:                gin.ReleaseMode,
 		Timeout:             time.Millisecond * 1000,
 		SlowRequestDuration: 500 * time.Millisecond,
+		ReadHeaderTimeout:   5 * time.Second,
+		IdleTimeout:         90 * time.Second,
 	}
 }
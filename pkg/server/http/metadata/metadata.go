@@ -19,21 +19,38 @@
 package metadata
 
 import (
+	"bytes"
+	"io"
+	"io/ioutil"
 	"net/http"
 	"strconv"
 	"time"
 )
 
 const (
-	HeaderContentType    = "Content-Type"
-	HeaderUserAgent      = "User-Agent"
-	HeaderAppkey         = "Appkey"
-	HeaderTimestamp      = "Timestamp"
-	HeaderSign           = "Sign"
-	HeaderNonce          = "Nonce"
-	HeaderAcceptLanguage = "Accept-Language"
-	HeaderHttpTimeout    = "X-Request-Timeout"
-	HeaderHttpTraceId    = "X-Trace-Id"
+	HeaderContentType        = "Content-Type"
+	HeaderUserAgent          = "User-Agent"
+	HeaderAppkey             = "Appkey"
+	HeaderTimestamp          = "Timestamp"
+	HeaderSign               = "Sign"
+	HeaderNonce              = "Nonce"
+	HeaderAcceptLanguage     = "Accept-Language"
+	HeaderHttpTimeout        = "X-Request-Timeout"
+	HeaderHttpTraceId        = "X-Trace-Id"
+	HeaderRequestId          = "X-Request-Id"
+	HeaderAcceptEncoding     = "Accept-Encoding"
+	HeaderContentEncoding    = "Content-Encoding"
+	HeaderContentLength      = "Content-Length"
+	HeaderVary               = "Vary"
+	HeaderRetryAfter         = "Retry-After"
+	HeaderRateLimitLimit     = "X-RateLimit-Limit"
+	HeaderRateLimitRemaining = "X-RateLimit-Remaining"
+	HeaderRateLimitReset     = "X-RateLimit-Reset"
+	HeaderIdempotencyKey     = "Idempotency-Key"
+	HeaderSignMethod         = "Sign-Method"
+	HeaderAuthorization      = "Authorization"
+
+	SignMethodHmacSHA256 = "HMAC-SHA256"
 
 	DefaultContentTypeJson = "application/json;charset=utf-8"
 	DefaultUserAgentVal    = "waterdrop"
@@ -55,3 +72,42 @@ func GetTimeout(req *http.Request) time.Duration {
 
 	return time.Duration(timeout) * time.Millisecond
 }
+
+// SignContent returns the canonical content of request to sign, both http client and server
+// sign the same content: query params of the final url encoded as `"bar=baz&foo=quux"` sorted
+// by key, followed by the exact body bytes on the wire, which is empty if there is no body.
+// Body is still readable after that
+func SignContent(req *http.Request) (string, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return "", err
+	}
+	return req.URL.Query().Encode() + string(body), nil
+}
+
+// readBody reads a copy of request body, body is restored if it has to be consumed
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+
+	if req.GetBody != nil {
+		rc, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return ioutil.ReadAll(rc)
+	}
+
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	req.Body.Close()
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(body)), nil
+	}
+	return body, nil
+}
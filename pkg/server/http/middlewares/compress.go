@@ -0,0 +1,281 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package middlewares
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/UnderTreeTech/waterdrop/pkg/server/http/metadata"
+	"github.com/UnderTreeTech/waterdrop/pkg/utils/xstring"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	encodingGzip    = "gzip"
+	encodingDeflate = "deflate"
+)
+
+// CompressConfig response compression config
+type CompressConfig struct {
+	// Level compression level, such as gzip.BestSpeed, defaults to gzip.DefaultCompression
+	Level int
+	// MinSize responses smaller than it are not compressed, defaults to 1024 bytes
+	MinSize int
+	// ContentTypes content types of responses to be compressed, defaults to text and json like types
+	ContentTypes []string
+}
+
+// DefaultCompressConfig default compress config
+func DefaultCompressConfig() *CompressConfig {
+	return &CompressConfig{
+		Level:   gzip.DefaultCompression,
+		MinSize: 1024,
+		ContentTypes: []string{
+			"application/json",
+			"application/javascript",
+			"application/xml",
+			"text/html",
+			"text/plain",
+			"text/css",
+			"text/xml",
+		},
+	}
+}
+
+// compressor is implemented by gzip.Writer and flate.Writer
+type compressor interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// Compress compresses responses by gzip or deflate according to Accept-Encoding, gzip is preferred.
+// Responses smaller than MinSize or whose content type is not in ContentTypes are sent as is.
+func Compress(config *CompressConfig) gin.HandlerFunc {
+	if config == nil {
+		config = DefaultCompressConfig()
+	}
+
+	types := make(map[string]struct{}, len(config.ContentTypes))
+	for _, typ := range config.ContentTypes {
+		types[strings.ToLower(typ)] = struct{}{}
+	}
+
+	gzipPool := sync.Pool{New: func() interface{} {
+		w, err := gzip.NewWriterLevel(ioutil.Discard, config.Level)
+		if err != nil {
+			w = gzip.NewWriter(ioutil.Discard)
+		}
+		return w
+	}}
+	flatePool := sync.Pool{New: func() interface{} {
+		w, err := flate.NewWriter(ioutil.Discard, config.Level)
+		if err != nil {
+			w, _ = flate.NewWriter(ioutil.Discard, flate.DefaultCompression)
+		}
+		return w
+	}}
+
+	return func(c *gin.Context) {
+		encoding := acceptEncoding(c.GetHeader(metadata.HeaderAcceptEncoding))
+		if encoding == "" || c.Request.Method == http.MethodHead || isStream(c) {
+			c.Next()
+			return
+		}
+
+		pool := &gzipPool
+		if encoding == encodingDeflate {
+			pool = &flatePool
+		}
+
+		cw := &compressWriter{
+			ResponseWriter: c.Writer,
+			encoding:       encoding,
+			minSize:        config.MinSize,
+			types:          types,
+			pool:           pool,
+		}
+		c.Writer = cw
+		defer func() {
+			cw.close()
+			c.Writer = cw.ResponseWriter
+		}()
+
+		c.Next()
+	}
+}
+
+// acceptEncoding returns the preferred encoding supported, gzip or deflate
+func acceptEncoding(header string) string {
+	deflate := false
+	for _, part := range strings.Split(header, ",") {
+		coding := strings.TrimSpace(part)
+		if idx := strings.IndexByte(coding, ';'); idx >= 0 {
+			if q := strings.TrimSpace(coding[idx+1:]); q == "q=0" || q == "q=0.0" {
+				continue
+			}
+			coding = strings.TrimSpace(coding[:idx])
+		}
+
+		switch strings.ToLower(coding) {
+		case encodingGzip, "*":
+			return encodingGzip
+		case encodingDeflate:
+			deflate = true
+		}
+	}
+
+	if deflate {
+		return encodingDeflate
+	}
+	return ""
+}
+
+// compressWriter buffers response until min size reached, then decides whether to compress it
+type compressWriter struct {
+	gin.ResponseWriter
+	encoding string
+	minSize  int
+	types    map[string]struct{}
+	pool     *sync.Pool
+
+	buf     bytes.Buffer
+	decided bool
+	cw      compressor
+}
+
+// Write buffers data before the decision, then writes data to compressor or the underlying writer
+func (w *compressWriter) Write(data []byte) (int, error) {
+	if !w.decided {
+		if w.buf.Len()+len(data) < w.minSize {
+			return w.buf.Write(data)
+		}
+		if err := w.decide(); err != nil {
+			return 0, err
+		}
+	}
+
+	if w.cw != nil {
+		return w.cw.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+// WriteString writes string data
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write(xstring.StringToBytes(s))
+}
+
+// Written returns true if there is data written or buffered
+func (w *compressWriter) Written() bool {
+	return w.buf.Len() > 0 || w.ResponseWriter.Written()
+}
+
+// Flush makes the decision without waiting min size, so that streaming responses are not held
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		w.decide()
+	}
+	if w.cw != nil {
+		w.cw.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// Hijack hijacks connection, the buffered data is discarded
+func (w *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.decided = true
+	w.buf.Reset()
+	return w.ResponseWriter.Hijack()
+}
+
+// decide starts compression if the response is compressible, then writes the buffered data
+func (w *compressWriter) decide() error {
+	w.decided = true
+	if w.compressible() {
+		header := w.Header()
+		header.Set(metadata.HeaderContentEncoding, w.encoding)
+		header.Add(metadata.HeaderVary, metadata.HeaderAcceptEncoding)
+		header.Del(metadata.HeaderContentLength)
+
+		w.cw = w.pool.Get().(compressor)
+		w.cw.Reset(w.ResponseWriter)
+	}
+
+	if w.buf.Len() == 0 {
+		return nil
+	}
+
+	data := w.buf.Bytes()
+	w.buf = bytes.Buffer{}
+	if w.cw != nil {
+		_, err := w.cw.Write(data)
+		return err
+	}
+	_, err := w.ResponseWriter.Write(data)
+	return err
+}
+
+// compressible reports whether response status, encoding and content type allow compression
+func (w *compressWriter) compressible() bool {
+	switch w.Status() {
+	case http.StatusNoContent, http.StatusNotModified:
+		return false
+	}
+
+	header := w.Header()
+	if header.Get(metadata.HeaderContentEncoding) != "" {
+		return false
+	}
+
+	typ := header.Get(metadata.HeaderContentType)
+	if typ == "" {
+		return false
+	}
+	_, ok := w.types[strings.ToLower(xstring.StripContentType(typ))]
+	return ok
+}
+
+// close writes the buffered small response as is, or finishes compression
+func (w *compressWriter) close() {
+	if !w.decided {
+		w.decided = true
+		if w.buf.Len() > 0 {
+			w.ResponseWriter.Write(w.buf.Bytes())
+		}
+		return
+	}
+
+	if w.cw != nil {
+		w.cw.Close()
+		w.cw.Reset(ioutil.Discard)
+		w.pool.Put(w.cw)
+		w.cw = nil
+	}
+}
@@ -0,0 +1,89 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package middlewares
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAcceptEncoding(t *testing.T) {
+	assert.Equal(t, "gzip", acceptEncoding("gzip, deflate, br"))
+	assert.Equal(t, "deflate", acceptEncoding("deflate, gzip;q=0"))
+	assert.Equal(t, "gzip", acceptEncoding("*"))
+	assert.Equal(t, "", acceptEncoding("br"))
+	assert.Equal(t, "", acceptEncoding(""))
+}
+
+func TestCompress(t *testing.T) {
+	large := strings.Repeat("waterdrop", 200)
+	engine := gin.New()
+	engine.Use(Compress(nil))
+	engine.GET("/large", func(c *gin.Context) {
+		c.String(http.StatusOK, large)
+	})
+	engine.GET("/small", func(c *gin.Context) {
+		c.String(http.StatusOK, "waterdrop")
+	})
+	engine.GET("/binary", func(c *gin.Context) {
+		c.Data(http.StatusOK, "image/png", []byte(large))
+	})
+
+	serve := func(path, encoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept-Encoding", encoding)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve("/large", "gzip")
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+	reader, err := gzip.NewReader(w.Body)
+	assert.Nil(t, err)
+	body, _ := ioutil.ReadAll(reader)
+	assert.Equal(t, large, string(body))
+
+	w = serve("/large", "deflate")
+	assert.Equal(t, "deflate", w.Header().Get("Content-Encoding"))
+	body, _ = ioutil.ReadAll(flate.NewReader(bytes.NewReader(w.Body.Bytes())))
+	assert.Equal(t, large, string(body))
+
+	w = serve("/large", "")
+	assert.Equal(t, "", w.Header().Get("Content-Encoding"))
+	assert.Equal(t, large, w.Body.String())
+
+	w = serve("/small", "gzip")
+	assert.Equal(t, "", w.Header().Get("Content-Encoding"))
+	assert.Equal(t, "waterdrop", w.Body.String())
+
+	w = serve("/binary", "gzip")
+	assert.Equal(t, "", w.Header().Get("Content-Encoding"))
+	assert.Equal(t, large, w.Body.String())
+}
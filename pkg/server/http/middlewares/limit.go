@@ -0,0 +1,74 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package middlewares
+
+import (
+	"io"
+
+	"github.com/UnderTreeTech/waterdrop/pkg/server/http/metadata"
+	"github.com/UnderTreeTech/waterdrop/pkg/server/http/render"
+	"github.com/UnderTreeTech/waterdrop/pkg/status"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BodyLimit limits request body size of routes, the request with body exceeding limit bytes is
+// aborted with status.PayloadTooLarge. If limit is not positive, metadata.LimitBodyBytes is used.
+// Use it on route groups, such as r.Group("/upload", BodyLimit(10<<20))
+func BodyLimit(limit int64) gin.HandlerFunc {
+	if limit <= 0 {
+		limit = metadata.LimitBodyBytes
+	}
+
+	return func(c *gin.Context) {
+		if c.Request.ContentLength > limit {
+			render.Abort(c, status.PayloadTooLarge)
+			return
+		}
+
+		// content length may be unknown or faked, limit the body reading either
+		if c.Request.Body != nil {
+			c.Request.Body = &limitedBody{ReadCloser: c.Request.Body, remain: limit}
+		}
+		c.Next()
+	}
+}
+
+// limitedBody returns status.PayloadTooLarge once more than limit bytes are read
+type limitedBody struct {
+	io.ReadCloser
+	remain int64
+}
+
+// Read reads one more byte than remained to detect body exceeding the limit
+func (l *limitedBody) Read(p []byte) (n int, err error) {
+	if l.remain < 0 {
+		return 0, status.PayloadTooLarge
+	}
+
+	if int64(len(p)) > l.remain+1 {
+		p = p[:l.remain+1]
+	}
+	n, err = l.ReadCloser.Read(p)
+	l.remain -= int64(n)
+	if l.remain < 0 {
+		return n + int(l.remain), status.PayloadTooLarge
+	}
+	return
+}
@@ -0,0 +1,65 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package middlewares
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/UnderTreeTech/waterdrop/pkg/server/http/render"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBodyLimit(t *testing.T) {
+	engine := gin.New()
+	engine.Use(BodyLimit(8))
+	engine.POST("/limit", func(c *gin.Context) {
+		body, err := ioutil.ReadAll(c.Request.Body)
+		if err != nil {
+			render.Abort(c, err)
+			return
+		}
+		c.String(http.StatusOK, string(body))
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/limit", strings.NewReader("waterdrop"))
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+	// content length is unknown
+	req = httptest.NewRequest(http.MethodPost, "/limit", strings.NewReader("waterdrop"))
+	req.ContentLength = -1
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Contains(t, w.Body.String(), `{"code":605,"message":"payload too large"`)
+
+	req = httptest.NewRequest(http.MethodPost, "/limit", strings.NewReader("water"))
+	req.ContentLength = -1
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "water", w.Body.String())
+}
@@ -57,7 +57,9 @@ func Logger(config *config.ServerConfig) gin.HandlerFunc {
 			log.String("error", c.Errors.ByType(gin.ErrorTypePrivate).String()),
 		)
 
-		if duration >= config.SlowRequestDuration {
+		if isStream(c) {
+			log.Info(c.Request.Context(), "http-stream-access-log", fields...)
+		} else if duration >= config.SlowRequestDuration {
 			log.Warn(c.Request.Context(), "http-slow-access-log", fields...)
 		} else {
 			log.Info(c.Request.Context(), "http-access-log", fields...)
@@ -42,7 +42,10 @@ func Metric() gin.HandlerFunc {
 			ns = _defaultNamespace
 		}
 		metric.HTTPServerHandleCounter.Inc(c.FullPath(), c.Request.Method, ns, strconv.Itoa(c.Writer.Status()))
-		metric.HTTPServerReqDuration.Observe(time.Since(now).Seconds(), c.FullPath(), c.Request.Method, ns)
+		// duration of long-lived streams makes no sense in request latency
+		if !isStream(c) {
+			metric.HTTPServerReqDuration.Observe(time.Since(now).Seconds(), c.FullPath(), c.Request.Method, ns)
+		}
 	}
 }
 
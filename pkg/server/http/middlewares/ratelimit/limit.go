@@ -0,0 +1,110 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package ratelimit
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/UnderTreeTech/waterdrop/pkg/log"
+	baggage "github.com/UnderTreeTech/waterdrop/pkg/metadata"
+	limiters "github.com/UnderTreeTech/waterdrop/pkg/ratelimit"
+	"github.com/UnderTreeTech/waterdrop/pkg/server/http/metadata"
+	"github.com/UnderTreeTech/waterdrop/pkg/server/http/render"
+	"github.com/UnderTreeTech/waterdrop/pkg/status"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Limit return rate limit middleware with any Limiter, requests are limited by route by default.
+// If the limiter is a Taker, such as limiters in pkg/ratelimit, X-RateLimit-* headers are set.
+// Requests are allowed if Taker fails to take token, so that a broken limiter backend such as
+// redis doesn't block all the requests. Limited requests are rendered by render.Abort unless
+// fallback is set.
+func Limit(limiter Limiter, opts ...Option) gin.HandlerFunc {
+	limitOption := Apply(opts)
+	if limitOption.Strategy == nil {
+		limitOption.Strategy = ByRoute
+	}
+
+	taker, _ := limiter.(Taker)
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		key := limitOption.Strategy(c)
+
+		allowed := true
+		if taker != nil {
+			result, err := taker.Take(ctx, key)
+			if err != nil {
+				log.Warn(ctx, "take rate limit token fail", log.String("key", key), log.String("error", err.Error()))
+			} else {
+				setHeaders(c, result)
+				allowed = result.Allowed
+			}
+		} else {
+			allowed = limiter.Allow(ctx, key)
+		}
+
+		if !allowed {
+			if limitOption.Fallback != nil {
+				limitOption.Fallback(c)
+			} else {
+				render.Abort(c, status.LimitExceed)
+			}
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// ByRoute limits requests by method and route
+func ByRoute(c *gin.Context) string {
+	return c.Request.Method + ":" + c.FullPath()
+}
+
+// ByIP limits requests by client ip
+func ByIP(c *gin.Context) string {
+	return c.ClientIP()
+}
+
+// ByAppKey limits requests by appkey of header or baggage
+func ByAppKey(c *gin.Context) string {
+	if appkey := c.GetHeader(metadata.HeaderAppkey); appkey != "" {
+		return appkey
+	}
+	return baggage.GetAppKey(c.Request.Context())
+}
+
+// setHeaders sets quota headers, durations are rounded up to seconds
+func setHeaders(c *gin.Context, result *limiters.Result) {
+	header := c.Writer.Header()
+	header.Set(metadata.HeaderRateLimitLimit, strconv.FormatInt(result.Limit, 10))
+	header.Set(metadata.HeaderRateLimitRemaining, strconv.FormatInt(result.Remaining, 10))
+	header.Set(metadata.HeaderRateLimitReset, seconds(result.Reset))
+	if !result.Allowed {
+		header.Set(metadata.HeaderRetryAfter, seconds(result.RetryAfter))
+	}
+}
+
+// seconds formats duration in seconds rounded up
+func seconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
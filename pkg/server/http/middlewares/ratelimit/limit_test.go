@@ -0,0 +1,108 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/UnderTreeTech/waterdrop/pkg/log"
+	limiters "github.com/UnderTreeTech/waterdrop/pkg/ratelimit"
+	"github.com/UnderTreeTech/waterdrop/pkg/status"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type denyLimiter struct{}
+
+func (denyLimiter) Allow(context.Context, string) bool { return false }
+
+// errTaker fails to take tokens
+type errTaker struct{}
+
+func (errTaker) Allow(context.Context, string) bool { return false }
+func (errTaker) Take(context.Context, string) (*limiters.Result, error) {
+	return nil, errors.New("redis down")
+}
+
+func TestLimit(t *testing.T) {
+	engine := gin.New()
+	engine.GET("/limit", Limit(limiters.NewTokenBucket(1, 2), WithResourceStrategy(ByAppKey)), func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	serve := func(appkey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/limit", nil)
+		req.Header.Set("Appkey", appkey)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve("app")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", w.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, "1", w.Header().Get("X-RateLimit-Remaining"))
+	assert.Equal(t, "1", w.Header().Get("X-RateLimit-Reset"))
+
+	serve("app")
+	w = serve("app")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "0", w.Header().Get("X-RateLimit-Remaining"))
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+	assert.Contains(t, w.Body.String(), `"code":`+status.LimitExceed.Error())
+	assert.Contains(t, w.Body.String(), `"trace_id":`)
+
+	w = serve("another")
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestLimitFallback(t *testing.T) {
+	engine := gin.New()
+	engine.GET("/limit", Limit(denyLimiter{}, WithFallback(func(c *gin.Context) {
+		c.AbortWithStatus(http.StatusServiceUnavailable)
+	})), func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/limit", nil)
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "", w.Header().Get("X-RateLimit-Limit"))
+}
+
+func TestLimitTakeFail(t *testing.T) {
+	defer log.New(nil).Sync()
+	engine := gin.New()
+	engine.GET("/limit", Limit(errTaker{}), func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	// requests are allowed if taking token fails
+	req := httptest.NewRequest(http.MethodGet, "/limit", nil)
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "", w.Header().Get("X-RateLimit-Limit"))
+}
@@ -19,14 +19,16 @@
 package ratelimit
 
 import (
-	"context"
+	limiters "github.com/UnderTreeTech/waterdrop/pkg/ratelimit"
 
 	"github.com/gin-gonic/gin"
 )
 
-type Limiter interface {
-	Allow(context.Context, string) bool
-}
+// Limiter limits requests by key, see ratelimit.Limiter of pkg/ratelimit
+type Limiter = limiters.Limiter
+
+// Taker is a Limiter reporting quota of key, the quota is rendered in response headers
+type Taker = limiters.Taker
 
 type Option func(*LimitOpts)
 
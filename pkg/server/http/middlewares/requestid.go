@@ -0,0 +1,56 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package middlewares
+
+import (
+	baggage "github.com/UnderTreeTech/waterdrop/pkg/metadata"
+	"github.com/UnderTreeTech/waterdrop/pkg/server/http/metadata"
+	"github.com/UnderTreeTech/waterdrop/pkg/trace"
+	"github.com/UnderTreeTech/waterdrop/pkg/utils/xstring"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxRequestIDLen the request id longer than it is dropped and a new one is generated
+const maxRequestIDLen = 128
+
+// RequestID accepts X-Request-Id header or the request id in upstream baggage, generates one if absent.
+// The request id is attached to baggage, span and response header, so that it's logged along with
+// trace id and passed to downstream services. It should be used after Trace.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		id := c.GetHeader(metadata.HeaderRequestId)
+		if id == "" {
+			id = baggage.GetRequestID(ctx)
+		}
+		if id == "" || len(id) > maxRequestIDLen {
+			id = xstring.GenerateUUID()
+		}
+
+		ctx = baggage.WithRequestID(ctx, id)
+		if span := trace.SpanFromContext(ctx); span != nil {
+			span.SetTag("request_id", id)
+		}
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Writer.Header().Set(metadata.HeaderRequestId, id)
+		c.Next()
+	}
+}
@@ -0,0 +1,52 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	baggage "github.com/UnderTreeTech/waterdrop/pkg/metadata"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestID(t *testing.T) {
+	engine := gin.New()
+	engine.Use(RequestID())
+	engine.GET("/request/id", func(c *gin.Context) {
+		c.String(http.StatusOK, baggage.GetRequestID(c.Request.Context()))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/request/id", nil)
+	req.Header.Set("X-Request-Id", "req-1")
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	assert.Equal(t, "req-1", w.Body.String())
+	assert.Equal(t, "req-1", w.Header().Get("X-Request-Id"))
+
+	// request id is generated if absent
+	req = httptest.NewRequest(http.MethodGet, "/request/id", nil)
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	assert.Equal(t, 36, len(w.Body.String()))
+	assert.Equal(t, w.Body.String(), w.Header().Get("X-Request-Id"))
+}
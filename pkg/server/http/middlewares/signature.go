@@ -0,0 +1,161 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package middlewares
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/UnderTreeTech/waterdrop/pkg/database/redis"
+	"github.com/UnderTreeTech/waterdrop/pkg/log"
+	baggage "github.com/UnderTreeTech/waterdrop/pkg/metadata"
+	"github.com/UnderTreeTech/waterdrop/pkg/server/http/metadata"
+	"github.com/UnderTreeTech/waterdrop/pkg/server/http/render"
+	"github.com/UnderTreeTech/waterdrop/pkg/server/nonce"
+	"github.com/UnderTreeTech/waterdrop/pkg/status"
+	"github.com/UnderTreeTech/waterdrop/pkg/utils/xcrypto"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SecretStore looks up signature secret of appkey
+type SecretStore interface {
+	Secret(ctx context.Context, appkey string) (secret string, err error)
+}
+
+// SecretStoreFunc is an adapter to allow the use of ordinary functions as SecretStore
+type SecretStoreFunc func(ctx context.Context, appkey string) (secret string, err error)
+
+// Secret calls f(ctx, appkey)
+func (f SecretStoreFunc) Secret(ctx context.Context, appkey string) (string, error) {
+	return f(ctx, appkey)
+}
+
+// NonceStore records nonces to block replays, see nonce.Store
+type NonceStore = nonce.Store
+
+// NewRedisNonceStore returns a NonceStore shared by all instances, nonces are recorded with the key prefix
+func NewRedisNonceStore(r *redis.Redis, prefix string) NonceStore {
+	return nonce.NewRedisStore(r, prefix)
+}
+
+// NewMemoryNonceStore returns a NonceStore keeps at most size nonces in memory,
+// it only blocks replays to the same instance
+func NewMemoryNonceStore(size int) NonceStore {
+	return nonce.NewMemoryStore(size)
+}
+
+// VerifySignature verifies the signature signed by http client Signature, or by
+// MD5Signature if the request carries no Sign-Method header.
+// A request is rejected if its appkey is invalid, its timestamp is older or newer than expire,
+// its sign mismatches or its nonce has been seen. Replays are not checked if nonces is nil.
+// Body is read to verify the sign, at most metadata.LimitBodyBytes bytes are read unless
+// BodyLimit is used before VerifySignature, rejections are rendered by render.Abort
+func VerifySignature(secrets SecretStore, nonces NonceStore, expire time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		appkey := c.GetHeader(metadata.HeaderAppkey)
+		if appkey == "" {
+			render.Abort(c, status.AppKeyInvalid)
+			return
+		}
+
+		secret, err := secrets.Secret(ctx, appkey)
+		if err != nil || secret == "" {
+			log.Warn(ctx, "appkey secret not found", log.String("appkey", appkey), log.Any("error", err))
+			render.Abort(c, status.AppKeyInvalid)
+			return
+		}
+
+		ts := c.GetHeader(metadata.HeaderTimestamp)
+		unix, err := strconv.ParseInt(ts, 10, 64)
+		if err != nil {
+			render.Abort(c, status.SignCheckErr)
+			return
+		}
+		if gap := time.Since(time.Unix(unix, 0)); gap > expire || gap < -expire {
+			log.Warn(ctx, "stale signature timestamp", log.String("appkey", appkey), log.String("timestamp", ts))
+			render.Abort(c, status.SignCheckErr)
+			return
+		}
+
+		method := c.GetHeader(metadata.HeaderSignMethod)
+		if method != "" && method != metadata.SignMethodHmacSHA256 {
+			render.Abort(c, status.SignCheckErr)
+			return
+		}
+
+		// body is read to verify the sign, limit the reading if BodyLimit isn't used ahead
+		if _, ok := c.Request.Body.(*limitedBody); !ok && c.Request.Body != nil {
+			c.Request.Body = &limitedBody{ReadCloser: c.Request.Body, remain: metadata.LimitBodyBytes}
+		}
+
+		nonceValue := c.GetHeader(metadata.HeaderNonce)
+		sign, err := signRequest(c.Request, method, secret, ts, nonceValue)
+		if err != nil {
+			log.Warn(ctx, "read request body fail", log.String("appkey", appkey), log.String("error", err.Error()))
+			if err != status.PayloadTooLarge {
+				err = status.RequestErr
+			}
+			render.Abort(c, err)
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(sign), []byte(c.GetHeader(metadata.HeaderSign))) != 1 {
+			log.Warn(ctx, "signature mismatch", log.String("appkey", appkey), log.String("path", c.Request.URL.Path))
+			render.Abort(c, status.SignCheckErr)
+			return
+		}
+
+		// the nonce is kept until the timestamp expires, so it can't be replayed in the window
+		if nonces != nil {
+			added, err := nonces.Add(ctx, appkey+":"+nonceValue, 2*expire)
+			if err != nil {
+				log.Error(ctx, "record nonce fail", log.String("appkey", appkey), log.String("error", err.Error()))
+				render.Abort(c, status.ServerErr)
+				return
+			}
+			if !added {
+				render.Abort(c, status.RepeatedRequest)
+				return
+			}
+		}
+
+		c.Request = c.Request.WithContext(baggage.WithAppKey(ctx, appkey))
+		c.Next()
+	}
+}
+
+// signRequest recomputes request sign, it's the same as the algorithm of http client Signature
+// hex(hmac_sha256(secret, query params + body + timestamp + nonce)), or MD5Signature
+// md5(query params + body + secret + timestamp + nonce) if method is empty.
+// Query params and body are canonicalized by metadata.SignContent, body is restored after read
+func signRequest(req *http.Request, method string, secret string, ts string, nonce string) (string, error) {
+	content, err := metadata.SignContent(req)
+	if err != nil {
+		return "", err
+	}
+
+	if method == metadata.SignMethodHmacSHA256 {
+		return xcrypto.HmacSHA256ToString([]byte(secret), content+ts+nonce, xcrypto.HEX)
+	}
+	return xcrypto.HashToString(content+secret+ts+nonce, xcrypto.MD5, xcrypto.HEX)
+}
@@ -0,0 +1,159 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/UnderTreeTech/waterdrop/pkg/log"
+	baggage "github.com/UnderTreeTech/waterdrop/pkg/metadata"
+	"github.com/UnderTreeTech/waterdrop/pkg/server/http/client"
+	"github.com/UnderTreeTech/waterdrop/pkg/server/http/config"
+	"github.com/UnderTreeTech/waterdrop/pkg/server/http/metadata"
+	"github.com/UnderTreeTech/waterdrop/pkg/status"
+	"github.com/UnderTreeTech/waterdrop/pkg/utils/xcrypto"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newSignatureEngine() *gin.Engine {
+	secrets := SecretStoreFunc(func(ctx context.Context, appkey string) (string, error) {
+		if appkey == "waterdrop" {
+			return "secret", nil
+		}
+		return "", errors.New("appkey not found")
+	})
+
+	engine := gin.New()
+	engine.Use(VerifySignature(secrets, NewMemoryNonceStore(1024), time.Minute))
+	handler := func(c *gin.Context) {
+		c.String(http.StatusOK, baggage.GetAppKey(c.Request.Context()))
+	}
+	engine.GET("/signature", handler)
+	engine.POST("/signature", handler)
+	engine.DELETE("/signature", handler)
+	return engine
+}
+
+// signedRequest returns a request signed by md5(query + body + secret + timestamp + nonce)
+func signedRequest(method string, query url.Values, body string, appkey string, secret string, ts int64, nonce string) *http.Request {
+	timestamp := strconv.FormatInt(ts, 10)
+	sign, _ := xcrypto.HashToString(query.Encode()+body+secret+timestamp+nonce, xcrypto.MD5, xcrypto.HEX)
+	req := httptest.NewRequest(method, "/signature?"+query.Encode(), strings.NewReader(body))
+	req.Header.Set(metadata.HeaderAppkey, appkey)
+	req.Header.Set(metadata.HeaderTimestamp, timestamp)
+	req.Header.Set(metadata.HeaderNonce, nonce)
+	req.Header.Set(metadata.HeaderSign, sign)
+	return req
+}
+
+func TestVerifySignature(t *testing.T) {
+	defer log.New(nil).Sync()
+	engine := newSignatureEngine()
+	now := time.Now().Unix()
+	query := url.Values{"foo": {"bar"}, "a": {"b"}}
+
+	t.Run("round trip", func(t *testing.T) {
+		srv := httptest.NewServer(engine)
+		defer srv.Close()
+
+		requests := []struct {
+			name string
+			call func(cli *client.Client) ([]byte, error)
+		}{
+			{"get with query", func(cli *client.Client) ([]byte, error) {
+				return cli.RawGet(context.Background(), &client.Request{URI: "/signature", QueryParam: query})
+			}},
+			{"get with query in uri", func(cli *client.Client) ([]byte, error) {
+				return cli.RawGet(context.Background(), &client.Request{URI: "/signature?x=1", QueryParam: query})
+			}},
+			{"delete without body", func(cli *client.Client) ([]byte, error) {
+				return cli.RawDelete(context.Background(), &client.Request{URI: "/signature"})
+			}},
+			{"post json body", func(cli *client.Client) ([]byte, error) {
+				return cli.RawPost(context.Background(), &client.Request{URI: "/signature", QueryParam: query, Body: map[string]string{"name": "waterdrop"}})
+			}},
+			{"post string body", func(cli *client.Client) ([]byte, error) {
+				return cli.RawPost(context.Background(), &client.Request{URI: "/signature", Body: `{"a":1}`})
+			}},
+			{"post bytes body", func(cli *client.Client) ([]byte, error) {
+				return cli.RawPost(context.Background(), &client.Request{URI: "/signature", Body: []byte(`{"a":1}`)})
+			}},
+		}
+
+		methods := map[string]client.RequestMiddleware{"hmac-sha256": client.Signature, "md5": client.MD5Signature}
+		for name, sign := range methods {
+			cli := client.New(&config.ClientConfig{HostURL: srv.URL, Key: "waterdrop", Secret: "secret"})
+			cli.Use(sign)
+			for _, r := range requests {
+				reply, err := r.call(cli)
+				assert.Nil(t, err, name+" "+r.name)
+				assert.Equal(t, "waterdrop", string(reply), name+" "+r.name)
+			}
+		}
+	})
+
+	unsupported := signedRequest(http.MethodGet, query, "", "waterdrop", "secret", now, "nonce-5")
+	unsupported.Header.Set(metadata.HeaderSignMethod, "RSA")
+
+	cases := []struct {
+		name   string
+		req    *http.Request
+		status *status.Status
+	}{
+		{"invalid appkey", signedRequest(http.MethodGet, query, "", "unknown", "secret", now, "nonce-1"), status.AppKeyInvalid},
+		{"sign mismatch", signedRequest(http.MethodGet, query, "", "waterdrop", "wrong", now, "nonce-2"), status.SignCheckErr},
+		{"stale timestamp", signedRequest(http.MethodGet, query, "", "waterdrop", "secret", now-3600, "nonce-3"), status.SignCheckErr},
+		{"success", signedRequest(http.MethodPost, query, `{"id":1}`, "waterdrop", "secret", now, "nonce-4"), status.OK},
+		{"replay", signedRequest(http.MethodPost, query, `{"id":1}`, "waterdrop", "secret", now, "nonce-4"), status.RepeatedRequest},
+		{"unsupported sign method", unsupported, status.SignCheckErr},
+		{"body too large", signedRequest(http.MethodPost, query, strings.Repeat("a", metadata.LimitBodyBytes+1), "waterdrop", "secret", now, "nonce-6"), status.PayloadTooLarge},
+	}
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, cs.req)
+			assert.Equal(t, cs.status.HTTPStatus(), w.Code)
+			if cs.status != status.OK {
+				assert.Contains(t, w.Body.String(), `"code":`+cs.status.Error())
+				assert.Contains(t, w.Body.String(), `"trace_id":`)
+			}
+		})
+	}
+}
+
+func TestVerifySignatureLocale(t *testing.T) {
+	defer log.New(nil).Sync()
+	req := signedRequest(http.MethodGet, url.Values{}, "", "unknown", "secret", time.Now().Unix(), "nonce")
+	req.Header.Set(metadata.HeaderAcceptLanguage, "zh-CN")
+	w := httptest.NewRecorder()
+	newSignatureEngine().ServeHTTP(w, req)
+	assert.Equal(t, status.AppKeyInvalid.HTTPStatus(), w.Code)
+	assert.Contains(t, w.Body.String(), status.AppKeyInvalid.LocalizedMessage("zh"))
+}
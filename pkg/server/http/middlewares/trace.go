@@ -21,6 +21,8 @@ package middlewares
 import (
 	"context"
 
+	baggage "github.com/UnderTreeTech/waterdrop/pkg/metadata"
+	"github.com/UnderTreeTech/waterdrop/pkg/registry"
 	"github.com/UnderTreeTech/waterdrop/pkg/trace"
 
 	"github.com/UnderTreeTech/waterdrop/pkg/server/http/metadata"
@@ -52,9 +54,9 @@ func Trace(config *config.ServerConfig) gin.HandlerFunc {
 			timeout = reqTimeout
 		}
 
-		// if zero timeout config means never timeout
+		// if zero timeout config means never timeout, and long-lived streams never timeout either
 		var cancel func()
-		if timeout > 0 {
+		if timeout > 0 && !isStream(c) {
 			ctx, cancel = context.WithTimeout(ctx, timeout)
 		} else {
 			cancel = func() {}
@@ -64,9 +66,24 @@ func Trace(config *config.ServerConfig) gin.HandlerFunc {
 			cancel()
 		}()
 
+		// pass routing color and baggage to downstream services
+		if color := c.Request.Header.Get(registry.ColorHeader); color != "" {
+			ctx = registry.WithColor(ctx, color)
+		}
+		ctx = baggage.ExtractHeader(ctx, c.Request.Header)
+
 		c.Request = c.Request.WithContext(ctx)
 		c.Writer.Header().Set(metadata.HeaderHttpTraceId, trace.TraceID(ctx))
 
 		c.Next()
 	}
 }
+
+// StreamKey gin context key marks the requests of long-lived stream routes, such as server-sent events.
+// It's set by the route owner before the middlewares run, http server sets it for the routes of SSE and Upgrade
+const StreamKey = "waterdrop/stream"
+
+// isStream reports whether the request is a long-lived stream, such as websocket and server-sent events
+func isStream(c *gin.Context) bool {
+	return c.IsWebsocket() || c.GetBool(StreamKey)
+}
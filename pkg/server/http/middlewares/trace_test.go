@@ -26,6 +26,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	baggage "github.com/UnderTreeTech/waterdrop/pkg/metadata"
+	"github.com/UnderTreeTech/waterdrop/pkg/registry"
 	"github.com/UnderTreeTech/waterdrop/pkg/trace"
 
 	"github.com/UnderTreeTech/waterdrop/pkg/server/http/config"
@@ -80,3 +82,64 @@ func TestJaegerTrace(t *testing.T) {
 
 	assert.NotEqual(t, 0, len(w.Body.String()))
 }
+
+func TestTraceColor(t *testing.T) {
+	engine := gin.New()
+	engine.Use(Trace(config.DefaultServerConfig()))
+	engine.GET("/trace/color", func(ctx *gin.Context) {
+		ctx.String(http.StatusOK, registry.ColorFromContext(ctx.Request.Context()))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/trace/color", nil)
+	req.Header.Set(registry.ColorHeader, "canary")
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+
+	assert.Equal(t, "canary", w.Body.String())
+}
+
+func TestTraceBaggage(t *testing.T) {
+	engine := gin.New()
+	engine.Use(Trace(config.DefaultServerConfig()))
+	engine.GET("/trace/baggage", func(ctx *gin.Context) {
+		ctx.String(http.StatusOK, baggage.GetTenant(ctx.Request.Context()))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/trace/baggage", nil)
+	req.Header.Set("X-Md-Tenant", "waterdrop")
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+
+	assert.Equal(t, "waterdrop", w.Body.String())
+}
+
+func TestTraceStream(t *testing.T) {
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) {
+		if c.Query("stream") != "" {
+			c.Set(StreamKey, true)
+		}
+	}, Trace(config.DefaultServerConfig()))
+	engine.GET("/trace/stream", func(ctx *gin.Context) {
+		_, ok := ctx.Request.Context().Deadline()
+		ctx.String(http.StatusOK, fmt.Sprint(ok))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/trace/stream", nil)
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	assert.Equal(t, "true", w.Body.String())
+
+	// clients can't mark requests as streams
+	req = httptest.NewRequest(http.MethodGet, "/trace/stream", nil)
+	req.Header.Set("Accept", "text/event-stream")
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	assert.Equal(t, "true", w.Body.String())
+
+	// long-lived streams never timeout
+	req = httptest.NewRequest(http.MethodGet, "/trace/stream?stream=1", nil)
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	assert.Equal(t, "false", w.Body.String())
+}
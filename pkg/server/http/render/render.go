@@ -0,0 +1,82 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// Package render renders replies and errors of http handlers in the Response envelope.
+// It's shared by the http server and middlewares, so all rejections have the same format.
+package render
+
+import (
+	"context"
+
+	"github.com/UnderTreeTech/waterdrop/pkg/server/http/metadata"
+	"github.com/UnderTreeTech/waterdrop/pkg/status"
+	"github.com/UnderTreeTech/waterdrop/pkg/trace"
+	"github.com/UnderTreeTech/waterdrop/pkg/utils/xstring"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Response response envelope of handlers
+type Response struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data"`
+	TraceID string      `json:"trace_id"`
+}
+
+// Render renders data or error in the Response envelope, message is localized by Accept-Language
+// and http status is mapped from the status code of error
+func Render(c *gin.Context, data interface{}, err error) {
+	estatus := toStatus(err)
+	if err != nil {
+		data = nil
+	}
+
+	c.JSON(estatus.HTTPStatus(), &Response{
+		Code:    estatus.Code(),
+		Message: estatus.LocalizedMessage(Locale(c)),
+		Data:    data,
+		TraceID: trace.TraceID(c.Request.Context()),
+	})
+}
+
+// Abort renders the error and aborts the pending handlers
+func Abort(c *gin.Context, err error) {
+	Render(c, nil, err)
+	c.Abort()
+}
+
+// Locale returns the request locale language
+func Locale(c *gin.Context) string {
+	return xstring.GetLocaleLng(c.GetHeader(metadata.HeaderAcceptLanguage))
+}
+
+// toStatus converts error to status, errors of downstream rpc services are extracted
+func toStatus(err error) *status.Status {
+	switch e := err.(type) {
+	case nil:
+		return status.OK
+	case *status.Status:
+		return e
+	}
+
+	if err == context.Canceled || err == context.DeadlineExceeded {
+		return status.ExtractContextStatus(err)
+	}
+	return status.ExtractStatus(err)
+}
@@ -0,0 +1,125 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/UnderTreeTech/waterdrop/pkg/log"
+	baggage "github.com/UnderTreeTech/waterdrop/pkg/metadata"
+	"github.com/UnderTreeTech/waterdrop/pkg/server/http/metadata"
+	"github.com/UnderTreeTech/waterdrop/pkg/server/http/render"
+	"github.com/UnderTreeTech/waterdrop/pkg/status"
+	"github.com/UnderTreeTech/waterdrop/pkg/trace"
+	"github.com/UnderTreeTech/waterdrop/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// Response response envelope of handlers, see render.Response
+type Response = render.Response
+
+// Handle adapts a typed handler to gin handler, fn must be func(context.Context, *Req) (*Resp, error).
+// Req is bound from uri params, query params and body by content type, then validated by struct tags.
+// The reply or error is rendered in the Response envelope.
+func Handle(fn interface{}) gin.HandlerFunc {
+	fv := reflect.ValueOf(fn)
+	ft := fv.Type()
+	if ft.Kind() != reflect.Func || ft.NumIn() != 2 || ft.NumOut() != 2 ||
+		ft.In(0) != contextType || ft.In(1).Kind() != reflect.Ptr || ft.In(1).Elem().Kind() != reflect.Struct ||
+		ft.Out(1) != errorType {
+		panic(fmt.Sprintf("waterdrop: handler must be func(context.Context, *Req) (*Resp, error), got %s", ft.String()))
+	}
+	reqType := ft.In(1).Elem()
+
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		if lng := c.GetHeader(metadata.HeaderAcceptLanguage); lng != "" {
+			ctx = baggage.WithLocale(ctx, lng)
+		}
+
+		req := reflect.New(reqType)
+		if err := Bind(c, req.Interface()); err != nil {
+			log.Warn(ctx, "bind request fail", log.String("path", c.FullPath()), log.String("error", err.Error()))
+			// errors like status.PayloadTooLarge of body reading are kept
+			if _, ok := err.(*status.Status); !ok {
+				err = status.RequestErr
+			}
+			Render(c, nil, err)
+			return
+		}
+
+		if violations := validator.Validate(req.Interface(), locale(c)); len(violations) > 0 {
+			c.JSON(status.RequestErr.HTTPStatus(), &Response{
+				Code:    status.RequestErr.Code(),
+				Message: status.RequestErr.LocalizedMessage(locale(c)),
+				Data:    violations,
+				TraceID: trace.TraceID(ctx),
+			})
+			return
+		}
+
+		out := fv.Call([]reflect.Value{reflect.ValueOf(ctx), req})
+		var err error
+		if !out[1].IsNil() {
+			err = out[1].Interface().(error)
+		}
+		Render(c, out[0].Interface(), err)
+	}
+}
+
+// Bind binds uri params, query params and body into obj, body is bound by content type
+func Bind(c *gin.Context, obj interface{}) error {
+	if len(c.Params) > 0 {
+		if err := c.ShouldBindUri(obj); err != nil {
+			return err
+		}
+	}
+
+	if len(c.Request.URL.RawQuery) > 0 {
+		if err := c.ShouldBindQuery(obj); err != nil {
+			return err
+		}
+	}
+
+	if c.Request.Method == http.MethodGet || c.Request.ContentLength == 0 {
+		return nil
+	}
+	return c.ShouldBindWith(obj, binding.Default(c.Request.Method, c.ContentType()))
+}
+
+// Render renders data or error in the Response envelope, message is localized by Accept-Language
+// and http status is mapped from the status code of error
+func Render(c *gin.Context, data interface{}, err error) {
+	render.Render(c, data, err)
+}
+
+// locale returns the request locale language
+func locale(c *gin.Context) string {
+	return render.Locale(c)
+}
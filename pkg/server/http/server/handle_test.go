@@ -0,0 +1,114 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/UnderTreeTech/waterdrop/pkg/status"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type greetReq struct {
+	ID   int64  `uri:"id" validate:"required"`
+	Name string `json:"name" form:"name" validate:"required,max=8"`
+}
+
+type greetResp struct {
+	ID      int64  `json:"id"`
+	Content string `json:"content"`
+}
+
+func greet(ctx context.Context, req *greetReq) (*greetResp, error) {
+	if req.Name == "root" {
+		return nil, status.AccessDenied
+	}
+	return &greetResp{ID: req.ID, Content: "hello " + req.Name}, nil
+}
+
+func serve(method, target, body string, header map[string]string) (*httptest.ResponseRecorder, *Response) {
+	engine := gin.New()
+	engine.Handle(http.MethodGet, "/greet/:id", Handle(greet))
+	engine.Handle(http.MethodPost, "/greet/:id", Handle(greet))
+
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+
+	resp := &Response{}
+	json.Unmarshal(w.Body.Bytes(), resp)
+	return w, resp
+}
+
+func TestHandle(t *testing.T) {
+	t.Run("query", func(t *testing.T) {
+		w, resp := serve(http.MethodGet, "/greet/1?name=drop", "", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, 0, resp.Code)
+		assert.Equal(t, "ok", resp.Message)
+		data := resp.Data.(map[string]interface{})
+		assert.Equal(t, float64(1), data["id"])
+		assert.Equal(t, "hello drop", data["content"])
+	})
+
+	t.Run("json body", func(t *testing.T) {
+		w, resp := serve(http.MethodPost, "/greet/2", `{"name":"water"}`, map[string]string{"Content-Type": "application/json"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "hello water", resp.Data.(map[string]interface{})["content"])
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		w, resp := serve(http.MethodGet, "/greet/3?name=waterdrops", "", map[string]string{"Accept-Language": "en-US,en;q=0.9"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, status.RequestErr.Code(), resp.Code)
+		assert.Equal(t, "bad request", resp.Message)
+		violations := resp.Data.([]interface{})
+		assert.Equal(t, 1, len(violations))
+		assert.Equal(t, "name", violations[0].(map[string]interface{})["field"])
+	})
+
+	t.Run("bind", func(t *testing.T) {
+		w, resp := serve(http.MethodGet, "/greet/abc?name=drop", "", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, status.RequestErr.Code(), resp.Code)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		w, resp := serve(http.MethodGet, "/greet/4?name=root", "", map[string]string{"Accept-Language": "zh-CN"})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Equal(t, status.AccessDenied.Code(), resp.Code)
+		assert.Equal(t, status.AccessDenied.Message(), resp.Message)
+		assert.Nil(t, resp.Data)
+	})
+}
+
+func TestHandlePanic(t *testing.T) {
+	assert.Panics(t, func() { Handle(func(req *greetReq) {}) })
+	assert.Panics(t, func() { Handle(func(ctx context.Context, req greetReq) (*greetResp, error) { return nil, nil }) })
+}
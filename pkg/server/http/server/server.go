@@ -20,18 +20,27 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"net"
 	"net/http"
+	"strings"
+	"sync"
 
 	"github.com/UnderTreeTech/waterdrop/pkg/server/http/config"
 
+	"github.com/UnderTreeTech/waterdrop/pkg/server/http/sse"
 	"github.com/UnderTreeTech/waterdrop/pkg/server/http/websocket"
 
 	"github.com/UnderTreeTech/waterdrop/pkg/server/http/middlewares"
 
 	"github.com/gin-gonic/gin"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"google.golang.org/grpc"
 
 	_ "github.com/UnderTreeTech/waterdrop/pkg/version"
 	// Automatically set GOMAXPROCS to match Linux container CPU quota
@@ -43,6 +52,12 @@ type Server struct {
 	*gin.Engine
 	Server *http.Server
 	config *config.ServerConfig
+
+	mutex      sync.Mutex
+	websockets []*websocket.WebSocket
+	// streams full paths of long-lived stream routes
+	streams    sync.Map
+	grpcServer *grpc.Server
 }
 
 // New returns a http server instance
@@ -57,7 +72,14 @@ func New(cfg *config.ServerConfig) *Server {
 		config: cfg,
 	}
 
-	srv.Use(middlewares.Recovery(), middlewares.Trace(srv.config), middlewares.Logger(srv.config), middlewares.Metric())
+	srv.Use(
+		srv.markStream,
+		middlewares.Recovery(),
+		middlewares.Trace(srv.config),
+		middlewares.RequestID(),
+		middlewares.Logger(srv.config),
+		middlewares.Metric(),
+	)
 	return srv
 }
 
@@ -68,13 +90,31 @@ func (s *Server) Start() net.Addr {
 		panic(fmt.Sprintf("http server: listen tcp fail,err msg %s", err.Error()))
 	}
 
+	tlsConfig, err := s.tlsConfig()
+	if err != nil {
+		panic(fmt.Sprintf("http server: load tls config fail,err msg %s", err.Error()))
+	}
+
 	s.Server = &http.Server{
-		Addr:    s.config.Addr,
-		Handler: s,
+		Addr:              s.config.Addr,
+		Handler:           s.handler(tlsConfig != nil),
+		TLSConfig:         tlsConfig,
+		ReadHeaderTimeout: s.config.ReadHeaderTimeout,
+		ReadTimeout:       s.config.ReadTimeout,
+		WriteTimeout:      s.config.WriteTimeout,
+		IdleTimeout:       s.config.IdleTimeout,
+		MaxHeaderBytes:    s.config.MaxHeaderBytes,
 	}
 
 	go func() {
-		if err := s.Server.Serve(listener); err != nil {
+		var err error
+		if tlsConfig != nil {
+			err = s.Server.ServeTLS(listener, "", "")
+		} else {
+			err = s.Server.Serve(listener)
+		}
+
+		if err != nil {
 			if err == http.ErrServerClosed {
 				log.Printf("waterdrop: http server closed")
 				return
@@ -87,14 +127,113 @@ func (s *Server) Start() net.Addr {
 	return listener.Addr()
 }
 
-// Stop shutdown server graceful
+// ServeGRPC serves grpc requests on the same port, requests are dispatched by protocol and content type.
+// It requires tls or h2c enabled, since grpc runs on HTTP/2 only.
+func (s *Server) ServeGRPC(grpcServer *grpc.Server) {
+	s.grpcServer = grpcServer
+}
+
+// handler returns the handler dispatching grpc requests to grpc server and the others to gin engine
+func (s *Server) handler(tls bool) http.Handler {
+	var handler http.Handler = s
+	if s.grpcServer != nil {
+		if !tls && !s.config.H2C {
+			panic("http server: serve grpc requires tls or h2c enabled")
+		}
+
+		handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+				s.grpcServer.ServeHTTP(w, r)
+				return
+			}
+			s.ServeHTTP(w, r)
+		})
+	}
+
+	if s.config.H2C && !tls {
+		handler = h2c.NewHandler(handler, &http2.Server{IdleTimeout: s.config.IdleTimeout})
+	}
+	return handler
+}
+
+// TLS reports whether the server serves https
+func (s *Server) TLS() bool {
+	return s.config.CertFile != "" && s.config.KeyFile != ""
+}
+
+// tlsConfig returns tls config if certificate is configured, clients are verified by client ca if it's configured
+func (s *Server) tlsConfig() (*tls.Config, error) {
+	if !s.TLS() {
+		return nil, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(s.config.CertFile, s.config.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+		NextProtos:   []string{"h2", "http/1.1"},
+	}
+
+	if s.config.ClientCAFile != "" {
+		pem, err := ioutil.ReadFile(s.config.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in %s", s.config.ClientCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsConfig, nil
+}
+
+// Stop shutdown server graceful, websocket sessions are closed with going away
+// since hijacked connections are not tracked by http server
 func (s *Server) Stop(ctx context.Context) error {
-	return s.Server.Shutdown(ctx)
+	err := s.Server.Shutdown(ctx)
+
+	s.mutex.Lock()
+	websockets := s.websockets
+	s.mutex.Unlock()
+	for _, ws := range websockets {
+		if werr := ws.Close(ctx); werr != nil && err == nil {
+			err = werr
+		}
+	}
+	return err
 }
 
 // Upgrade upgrade http to websocket
 func (s *Server) Upgrade(ws *websocket.WebSocket) gin.IRoutes {
+	s.mutex.Lock()
+	s.websockets = append(s.websockets, ws)
+	s.mutex.Unlock()
+
+	s.streams.Store(ws.Path, struct{}{})
 	return s.GET(ws.Path, func(c *gin.Context) {
 		ws.Upgrade(c.Writer, c.Request)
 	})
 }
+
+// SSE serves server-sent events stream
+func (s *Server) SSE(stream *sse.Stream) gin.IRoutes {
+	s.streams.Store(stream.Path, struct{}{})
+	return s.GET(stream.Path, func(c *gin.Context) {
+		stream.Serve(c.Writer, c.Request)
+	})
+}
+
+// markStream marks the requests of long-lived stream routes, so that they're kept out of
+// request timeout, duration metrics and compression
+func (s *Server) markStream(c *gin.Context) {
+	if _, ok := s.streams.Load(c.FullPath()); ok {
+		c.Set(middlewares.StreamKey, true)
+	}
+}
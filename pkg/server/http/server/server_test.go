@@ -20,14 +20,19 @@ package server
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 	"time"
 
 	"github.com/UnderTreeTech/waterdrop/pkg/log"
+	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/UnderTreeTech/waterdrop/pkg/server/http/config"
+	"github.com/UnderTreeTech/waterdrop/pkg/server/http/sse"
 )
 
 var srv = New(config.DefaultServerConfig())
@@ -54,3 +59,26 @@ func TestStop(t *testing.T) {
 
 	time.Sleep(200 * time.Millisecond)
 }
+
+func TestStream(t *testing.T) {
+	s := New(nil)
+	deadline := func(c *gin.Context) {
+		_, ok := c.Request.Context().Deadline()
+		c.String(http.StatusOK, fmt.Sprint(ok))
+	}
+	s.GET("/plain", deadline)
+	s.streams.Store("/stream", struct{}{})
+	s.GET("/stream", deadline)
+
+	serve := func(path string) string {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept", sse.ContentType)
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, req)
+		return w.Body.String()
+	}
+
+	// only the stream routes marked by server are kept out of request timeout
+	assert.Equal(t, "true", serve("/plain"))
+	assert.Equal(t, "false", serve("/stream"))
+}
@@ -0,0 +1,174 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/UnderTreeTech/waterdrop/pkg/server/http/config"
+	"github.com/UnderTreeTech/waterdrop/tests/proto/demo"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/http2"
+	"google.golang.org/grpc"
+)
+
+// issue issues a certificate signed by parent, it's self-signed if parent is nil
+func issue(t *testing.T, cn string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey, []byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		parent, parentKey = tmpl, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	assert.Nil(t, err)
+	cert, _ := x509.ParseCertificate(der)
+	keyDer, _ := x509.MarshalECPrivateKey(key)
+	return cert, key,
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+}
+
+// write writes content to file in dir
+func write(t *testing.T, dir string, name string, content []byte) string {
+	path := filepath.Join(dir, name)
+	assert.Nil(t, ioutil.WriteFile(path, content, 0600))
+	return path
+}
+
+func TestTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca, caKey, caPem, _ := issue(t, "ca", nil, nil)
+	_, _, serverPem, serverKey := issue(t, "server", ca, caKey)
+	_, _, clientPem, clientKey := issue(t, "client", ca, caKey)
+
+	cfg := config.DefaultServerConfig()
+	cfg.Addr = "127.0.0.1:0"
+	cfg.CertFile = write(t, dir, "server.pem", serverPem)
+	cfg.KeyFile = write(t, dir, "server.key", serverKey)
+	cfg.ClientCAFile = write(t, dir, "ca.pem", caPem)
+
+	srv := New(cfg)
+	srv.GET("/tls", func(c *gin.Context) {
+		c.String(http.StatusOK, c.Request.Proto)
+	})
+	addr := srv.Start()
+	defer srv.Stop(context.Background())
+
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(caPem)
+	clientCert, err := tls.X509KeyPair(clientPem, clientKey)
+	assert.Nil(t, err)
+
+	client := &http.Client{Transport: &http2.Transport{TLSClientConfig: &tls.Config{
+		RootCAs:      pool,
+		Certificates: []tls.Certificate{clientCert},
+	}}}
+	resp, err := client.Get("https://" + addr.String() + "/tls")
+	assert.Nil(t, err)
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, "HTTP/2.0", string(body))
+
+	// client certificate is required
+	client = &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+	_, err = client.Get("https://" + addr.String() + "/tls")
+	assert.NotNil(t, err)
+}
+
+type demoService struct {
+	demo.UnimplementedDemoServer
+}
+
+func (*demoService) SayHelloURL(ctx context.Context, req *demo.HelloReq) (*demo.HelloResp, error) {
+	return &demo.HelloResp{Content: "hello " + req.Name}, nil
+}
+
+func TestServeGRPC(t *testing.T) {
+	cfg := config.DefaultServerConfig()
+	cfg.Addr = "127.0.0.1:0"
+	cfg.H2C = true
+
+	grpcServer := grpc.NewServer()
+	demo.RegisterDemoServer(grpcServer, &demoService{})
+
+	srv := New(cfg)
+	srv.GET("/h2c", func(c *gin.Context) {
+		c.String(http.StatusOK, c.Request.Proto)
+	})
+	srv.ServeGRPC(grpcServer)
+	addr := srv.Start()
+	defer srv.Stop(context.Background())
+
+	// http/1.1 requests
+	resp, err := http.Get("http://" + addr.String() + "/h2c")
+	assert.Nil(t, err)
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, "HTTP/1.1", string(body))
+
+	// cleartext http/2 requests
+	client := &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLS: func(network, addr string, cfg *tls.Config) (net.Conn, error) {
+			return net.Dial(network, addr)
+		},
+	}}
+	resp, err = client.Get("http://" + addr.String() + "/h2c")
+	assert.Nil(t, err)
+	body, _ = ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, "HTTP/2.0", string(body))
+
+	// grpc requests on the same port
+	cc, err := grpc.Dial(addr.String(), grpc.WithInsecure())
+	assert.Nil(t, err)
+	defer cc.Close()
+	reply, err := demo.NewDemoClient(cc).SayHelloURL(context.Background(), &demo.HelloReq{Name: "waterdrop"})
+	assert.Nil(t, err)
+	assert.Equal(t, "hello waterdrop", reply.Content)
+}
@@ -0,0 +1,88 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package sse
+
+import (
+	"sync"
+
+	"github.com/UnderTreeTech/waterdrop/pkg/utils/xcollection"
+)
+
+// defaultMaxKeys max keys kept by memory replayer, the least recently used keys are evicted
+const defaultMaxKeys = 1024
+
+// Replayer replay buffer of events, events are buffered by key
+type Replayer interface {
+	// Add adds event of key
+	Add(key string, event *Event)
+	// Since returns events of key after the event of lastID. All the buffered events are returned
+	// if the event of lastID is not buffered any more.
+	Since(key string, lastID string) []*Event
+}
+
+// MemoryReplayer in-memory replayer keeps the latest events of each key
+type MemoryReplayer struct {
+	size   int
+	events *xcollection.LRUCache
+	mutex  sync.Mutex
+}
+
+// NewMemoryReplayer returns a in-memory replayer keeping the latest size events of each key
+func NewMemoryReplayer(size int) *MemoryReplayer {
+	return &MemoryReplayer{
+		size:   size,
+		events: xcollection.NewLRU(defaultMaxKeys),
+	}
+}
+
+// Add adds event of key, the oldest event is dropped if the buffer is full
+func (m *MemoryReplayer) Add(key string, event *Event) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	var events []*Event
+	if val, ok := m.events.Get(key); ok {
+		events = val.([]*Event)
+	}
+
+	events = append(events, event)
+	if len(events) > m.size {
+		events = append([]*Event(nil), events[len(events)-m.size:]...)
+	}
+	m.events.Add(key, events)
+}
+
+// Since returns events of key after the event of lastID
+func (m *MemoryReplayer) Since(key string, lastID string) []*Event {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	val, ok := m.events.Get(key)
+	if !ok {
+		return nil
+	}
+
+	events := val.([]*Event)
+	for i := len(events) - 1; i >= 0; i-- {
+		if events[i].ID == lastID {
+			return append([]*Event(nil), events[i+1:]...)
+		}
+	}
+	return append([]*Event(nil), events...)
+}
@@ -0,0 +1,302 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// Package sse provides Server-Sent Events streams, events are written in text/event-stream
+// format with heartbeat comments, and the events missed by reconnected clients are replayed
+// from Last-Event-ID by a pluggable replay buffer.
+package sse
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/UnderTreeTech/waterdrop/pkg/log"
+)
+
+const (
+	// ContentType content type of event stream
+	ContentType = "text/event-stream"
+	// HeaderLastEventID header of the last event id received by a reconnected client
+	HeaderLastEventID = "Last-Event-ID"
+)
+
+// ErrStreamClosed stream is closed for client disconnected or handler returned
+var ErrStreamClosed = errors.New("sse: stream closed")
+
+// Event a server-sent event
+type Event struct {
+	// ID event id, events with id are added to replay buffer
+	ID string
+	// Event event type, clients listen on it by addEventListener
+	Event string
+	// Data event data, multiple lines are sent in multiple data fields
+	Data string
+	// Retry reconnection time of client
+	Retry time.Duration
+}
+
+// Handler stream handler, the stream is closed once the handler returns
+type Handler func(*Writer)
+
+// Config stream config
+type Config struct {
+	// Heartbeat interval of heartbeat comments, it keeps the connection alive through proxies.
+	// Zero disables heartbeat.
+	Heartbeat time.Duration
+	// Retry reconnection time sent to clients on connected, zero means browser default
+	Retry time.Duration
+	// Replay replay buffer of events, nil disables replay
+	Replay Replayer
+	// Key returns the replay key of request, events are replayed by key. Defaults to url path.
+	Key func(r *http.Request) string
+}
+
+// DefaultConfig default stream config
+func DefaultConfig() *Config {
+	return &Config{
+		Heartbeat: 15 * time.Second,
+	}
+}
+
+// Stream an event stream route
+type Stream struct {
+	Path    string
+	Handler Handler
+	config  *Config
+}
+
+// NewStream returns an event stream of path, default config is used if config is nil
+func NewStream(path string, handler Handler, config *Config) *Stream {
+	if config == nil {
+		config = DefaultConfig()
+	}
+	if config.Key == nil {
+		config.Key = func(r *http.Request) string { return r.URL.Path }
+	}
+
+	return &Stream{
+		Path:    path,
+		Handler: handler,
+		config:  config,
+	}
+}
+
+// Serve serves event stream until handler returns or client disconnected.
+// Clients should request with Accept: text/event-stream, so that middlewares treat it as a
+// long-lived stream without request timeout.
+func (s *Stream) Serve(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	header := w.Header()
+	header.Set("Content-Type", ContentType)
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	// disable proxy buffering, such as nginx
+	header.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	lastEventID := r.Header.Get(HeaderLastEventID)
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("lastEventId")
+	}
+
+	writer := &Writer{
+		ctx:         ctx,
+		cancel:      cancel,
+		w:           w,
+		flusher:     flusher,
+		replay:      s.config.Replay,
+		key:         s.config.Key(r),
+		lastEventID: lastEventID,
+		Request:     r,
+	}
+	defer writer.close()
+
+	if s.config.Retry > 0 {
+		writer.write(fmt.Sprintf("retry: %d\n\n", s.config.Retry.Milliseconds()))
+	} else {
+		writer.write(": connected\n\n")
+	}
+
+	if writer.replay != nil && lastEventID != "" {
+		for _, event := range writer.replay.Since(writer.key, lastEventID) {
+			if err := writer.send(event, false); err != nil {
+				return
+			}
+		}
+	}
+
+	if s.config.Heartbeat > 0 {
+		go writer.heartbeat(s.config.Heartbeat)
+	}
+
+	s.Handler(writer)
+}
+
+// Writer writes events of a stream, it's safe for concurrent use
+type Writer struct {
+	// Request the stream request
+	Request *http.Request
+
+	ctx         context.Context
+	cancel      context.CancelFunc
+	w           io.Writer
+	flusher     http.Flusher
+	replay      Replayer
+	key         string
+	lastEventID string
+	mutex       sync.Mutex
+}
+
+// Context returns the stream context, it's canceled once client disconnected or writing failed
+func (w *Writer) Context() context.Context {
+	return w.ctx
+}
+
+// Done returns a channel closed once stream closed
+func (w *Writer) Done() <-chan struct{} {
+	return w.ctx.Done()
+}
+
+// LastEventID returns the last event id received by client before reconnecting
+func (w *Writer) LastEventID() string {
+	return w.lastEventID
+}
+
+// Send sends event to client, the event with id is added to replay buffer
+func (w *Writer) Send(event *Event) error {
+	return w.send(event, true)
+}
+
+// SendJSON sends event with json data
+func (w *Writer) SendJSON(id string, event string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return w.Send(&Event{ID: id, Event: event, Data: string(data)})
+}
+
+// Comment sends comment, it's ignored by clients
+func (w *Writer) Comment(text string) error {
+	var sb strings.Builder
+	for _, line := range lines(text) {
+		sb.WriteString(": ")
+		sb.WriteString(line)
+		sb.WriteByte('\n')
+	}
+	sb.WriteByte('\n')
+	return w.write(sb.String())
+}
+
+// send encodes event and sends it
+func (w *Writer) send(event *Event, replay bool) error {
+	var sb strings.Builder
+	if event.ID != "" {
+		sb.WriteString("id: ")
+		sb.WriteString(oneLine(event.ID))
+		sb.WriteByte('\n')
+	}
+	if event.Event != "" {
+		sb.WriteString("event: ")
+		sb.WriteString(oneLine(event.Event))
+		sb.WriteByte('\n')
+	}
+	if event.Retry > 0 {
+		sb.WriteString(fmt.Sprintf("retry: %d\n", event.Retry.Milliseconds()))
+	}
+	for _, line := range lines(event.Data) {
+		sb.WriteString("data: ")
+		sb.WriteString(line)
+		sb.WriteByte('\n')
+	}
+	sb.WriteByte('\n')
+
+	if replay && w.replay != nil && event.ID != "" {
+		w.replay.Add(w.key, event)
+	}
+	return w.write(sb.String())
+}
+
+// write writes and flushes data, the stream is closed on error
+func (w *Writer) write(data string) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.ctx.Err() != nil {
+		return ErrStreamClosed
+	}
+
+	if _, err := io.WriteString(w.w, data); err != nil {
+		log.Warn(w.ctx, "sse write fail, close stream", log.String("error", err.Error()))
+		w.cancel()
+		return ErrStreamClosed
+	}
+	w.flusher.Flush()
+	return nil
+}
+
+// close closes stream, it waits for the writing in progress so that nothing is written
+// after the stream served
+func (w *Writer) close() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.cancel()
+}
+
+// heartbeat sends heartbeat comments until stream closed
+func (w *Writer) heartbeat(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.ctx.Done():
+			return
+		case <-ticker.C:
+			if err := w.write(": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// lines splits text by line terminators of sse spec, which are CRLF, LF and a lone CR
+func lines(text string) []string {
+	return strings.Split(strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(text), "\n")
+}
+
+// oneLine removes line breaks of field value
+func oneLine(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
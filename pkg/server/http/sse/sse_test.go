@@ -0,0 +1,148 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package sse
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/UnderTreeTech/waterdrop/pkg/log"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	defer log.New(nil).Sync()
+
+	code := m.Run()
+	os.Exit(code)
+}
+
+// connect requests the stream and returns a reader of lines
+func connect(t *testing.T, ctx context.Context, url string, lastEventID string) *bufio.Reader {
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	req.Header.Set("Accept", ContentType)
+	if lastEventID != "" {
+		req.Header.Set(HeaderLastEventID, lastEventID)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	assert.Nil(t, err)
+	assert.Equal(t, ContentType, resp.Header.Get("Content-Type"))
+	return bufio.NewReader(resp.Body)
+}
+
+// readBlock reads lines until a blank line
+func readBlock(t *testing.T, reader *bufio.Reader) string {
+	var lines []string
+	for {
+		line, err := reader.ReadString('\n')
+		assert.Nil(t, err)
+		if line == "\n" {
+			return strings.Join(lines, "")
+		}
+		lines = append(lines, line)
+	}
+}
+
+func TestStream(t *testing.T) {
+	config := DefaultConfig()
+	config.Retry = 3 * time.Second
+	config.Replay = NewMemoryReplayer(10)
+	stream := NewStream("/events", func(w *Writer) {
+		// missed events are replayed from replay buffer
+		if w.LastEventID() != "" {
+			return
+		}
+		for i := 1; i <= 3; i++ {
+			assert.Nil(t, w.Send(&Event{ID: strconv.Itoa(i), Event: "progress", Data: "line1\nline" + strconv.Itoa(i)}))
+		}
+		assert.Nil(t, w.SendJSON("", "done", map[string]int{"total": 3}))
+	}, config)
+
+	srv := httptest.NewServer(http.HandlerFunc(stream.Serve))
+	defer srv.Close()
+
+	reader := connect(t, context.Background(), srv.URL+"/events", "")
+	assert.Equal(t, "retry: 3000\n", readBlock(t, reader))
+	assert.Equal(t, "id: 1\nevent: progress\ndata: line1\ndata: line1\n", readBlock(t, reader))
+	assert.Equal(t, "id: 2\nevent: progress\ndata: line1\ndata: line2\n", readBlock(t, reader))
+	assert.Equal(t, "id: 3\nevent: progress\ndata: line1\ndata: line3\n", readBlock(t, reader))
+	assert.Equal(t, "event: done\ndata: {\"total\":3}\n", readBlock(t, reader))
+
+	// events after last event id are replayed
+	reader = connect(t, context.Background(), srv.URL+"/events", "1")
+	assert.Equal(t, "retry: 3000\n", readBlock(t, reader))
+	assert.Equal(t, "id: 2\nevent: progress\ndata: line1\ndata: line2\n", readBlock(t, reader))
+	assert.Equal(t, "id: 3\nevent: progress\ndata: line1\ndata: line3\n", readBlock(t, reader))
+}
+
+func TestHeartbeatAndDisconnect(t *testing.T) {
+	closed := make(chan struct{})
+	config := &Config{Heartbeat: 20 * time.Millisecond}
+	stream := NewStream("/heartbeat", func(w *Writer) {
+		<-w.Done()
+		assert.Equal(t, ErrStreamClosed, w.Send(&Event{Data: "gone"}))
+		close(closed)
+	}, config)
+
+	srv := httptest.NewServer(http.HandlerFunc(stream.Serve))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	reader := connect(t, ctx, srv.URL+"/heartbeat", "")
+	assert.Equal(t, ": connected\n", readBlock(t, reader))
+	assert.Equal(t, ": heartbeat\n", readBlock(t, reader))
+
+	cancel()
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("disconnect not detected")
+	}
+}
+
+func TestMemoryReplayer(t *testing.T) {
+	replayer := NewMemoryReplayer(2)
+	for _, id := range []string{"1", "2", "3"} {
+		replayer.Add("key", &Event{ID: id})
+	}
+
+	events := replayer.Since("key", "2")
+	assert.Equal(t, 1, len(events))
+	assert.Equal(t, "3", events[0].ID)
+
+	// event 1 is dropped, all the buffered events are replayed
+	assert.Equal(t, 2, len(replayer.Since("key", "1")))
+	assert.Equal(t, 0, len(replayer.Since("key", "3")))
+	assert.Nil(t, replayer.Since("unknown", "1"))
+}
+
+func TestLines(t *testing.T) {
+	assert.Equal(t, []string{"a", "b", "c", "d"}, lines("a\r\nb\nc\rd"))
+	// lone CR can't inject fields
+	assert.Equal(t, []string{"x", "id: 1", "event: y"}, lines("x\rid: 1\revent: y"))
+}
@@ -0,0 +1,249 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package websocket
+
+import (
+	"context"
+	"sync"
+
+	"github.com/UnderTreeTech/waterdrop/pkg/stats/metric"
+
+	"github.com/gorilla/websocket"
+)
+
+// Hub manages sessions of a websocket path, it supports rooms, broadcast and per-user fan-out
+type Hub struct {
+	path   string
+	config *Config
+
+	mutex    sync.RWMutex
+	sessions map[*Session]struct{}
+	rooms    map[string]map[*Session]struct{}
+	users    map[string]map[*Session]struct{}
+	closed   bool
+	wg       sync.WaitGroup
+}
+
+// newHub returns a hub of path
+func newHub(path string, config *Config) *Hub {
+	return &Hub{
+		path:     path,
+		config:   config,
+		sessions: make(map[*Session]struct{}),
+		rooms:    make(map[string]map[*Session]struct{}),
+		users:    make(map[string]map[*Session]struct{}),
+	}
+}
+
+// Len returns the number of sessions
+func (h *Hub) Len() int {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return len(h.sessions)
+}
+
+// Broadcast sends message to all the sessions
+func (h *Hub) Broadcast(messageType int, data []byte) {
+	h.mutex.RLock()
+	sessions := collect(h.sessions)
+	h.mutex.RUnlock()
+
+	send(sessions, messageType, data)
+}
+
+// BroadcastRoom sends message to the sessions in room
+func (h *Hub) BroadcastRoom(room string, messageType int, data []byte) {
+	h.mutex.RLock()
+	sessions := collect(h.rooms[room])
+	h.mutex.RUnlock()
+
+	send(sessions, messageType, data)
+}
+
+// SendToUser sends message to all the sessions of user
+func (h *Hub) SendToUser(uid string, messageType int, data []byte) {
+	h.mutex.RLock()
+	sessions := collect(h.users[uid])
+	h.mutex.RUnlock()
+
+	send(sessions, messageType, data)
+}
+
+// Close closes all the sessions with going away, new sessions are rejected.
+// It waits for the handlers of sessions returned until ctx done.
+func (h *Hub) Close(ctx context.Context) error {
+	h.mutex.Lock()
+	h.closed = true
+	sessions := collect(h.sessions)
+	h.mutex.Unlock()
+
+	for _, session := range sessions {
+		session.close(websocket.CloseGoingAway)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		h.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// isClosed reports whether the hub is closed
+func (h *Hub) isClosed() bool {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return h.closed
+}
+
+// add adds session to hub, it returns false if the hub is closed
+func (h *Hub) add(s *Session) bool {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if h.closed {
+		return false
+	}
+
+	h.wg.Add(1)
+	h.sessions[s] = struct{}{}
+	metric.WebSocketConnections.Inc(h.path)
+	return true
+}
+
+// done marks the handler of a session returned
+func (h *Hub) done() {
+	h.wg.Done()
+}
+
+// remove removes session from hub, rooms and users
+func (h *Hub) remove(s *Session) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if _, ok := h.sessions[s]; !ok {
+		return
+	}
+
+	delete(h.sessions, s)
+	for room := range s.rooms {
+		leave(h.rooms, room, s)
+	}
+	if s.uid != "" {
+		leave(h.users, s.uid, s)
+	}
+	metric.WebSocketConnections.Dec(h.path)
+}
+
+// join adds session to room
+func (h *Hub) join(room string, s *Session) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if _, ok := h.sessions[s]; !ok {
+		return
+	}
+
+	join(h.rooms, room, s)
+	s.rooms[room] = struct{}{}
+}
+
+// leave removes session from room
+func (h *Hub) leave(room string, s *Session) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	leave(h.rooms, room, s)
+	delete(s.rooms, room)
+}
+
+// bind binds session to user, the session is unbound from the previous user
+func (h *Hub) bind(uid string, s *Session) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if _, ok := h.sessions[s]; !ok {
+		return
+	}
+
+	if s.uid != "" {
+		leave(h.users, s.uid, s)
+	}
+	s.uid = uid
+	if uid != "" {
+		join(h.users, uid, s)
+	}
+}
+
+// sessionRooms returns rooms the session joined
+func (h *Hub) sessionRooms(s *Session) []string {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	rooms := make([]string, 0, len(s.rooms))
+	for room := range s.rooms {
+		rooms = append(rooms, room)
+	}
+	return rooms
+}
+
+// join adds session to group of key
+func join(groups map[string]map[*Session]struct{}, key string, s *Session) {
+	group, ok := groups[key]
+	if !ok {
+		group = make(map[*Session]struct{})
+		groups[key] = group
+	}
+	group[s] = struct{}{}
+}
+
+// leave removes session from group of key, the empty group is deleted
+func leave(groups map[string]map[*Session]struct{}, key string, s *Session) {
+	group, ok := groups[key]
+	if !ok {
+		return
+	}
+
+	delete(group, s)
+	if len(group) == 0 {
+		delete(groups, key)
+	}
+}
+
+// collect returns sessions in group
+func collect(group map[*Session]struct{}) []*Session {
+	sessions := make([]*Session, 0, len(group))
+	for s := range group {
+		sessions = append(sessions, s)
+	}
+	return sessions
+}
+
+// send sends message to sessions, errors of slow or closed sessions are ignored
+func send(sessions []*Session, messageType int, data []byte) {
+	for _, s := range sessions {
+		s.WriteMessage(messageType, data)
+	}
+}
@@ -0,0 +1,258 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package websocket
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/UnderTreeTech/waterdrop/pkg/log"
+	"github.com/UnderTreeTech/waterdrop/pkg/stats/metric"
+	"github.com/UnderTreeTech/waterdrop/pkg/utils/xstring"
+
+	"github.com/gorilla/websocket"
+)
+
+// message types, they are aliases of gorilla websocket message types
+const (
+	TextMessage   = websocket.TextMessage
+	BinaryMessage = websocket.BinaryMessage
+)
+
+var (
+	// ErrSessionClosed session is closed
+	ErrSessionClosed = errors.New("websocket: session closed")
+	// ErrSendBufferFull send buffer of session is full, the session is closed as it's too slow
+	ErrSendBufferFull = errors.New("websocket: send buffer full")
+)
+
+// message outgoing message
+type message struct {
+	messageType int
+	data        []byte
+}
+
+// Session a websocket connection, reading is allowed in one goroutine only and
+// writing is safe for concurrent use
+type Session struct {
+	// ID unique session id
+	ID string
+	// Request the upgrade request
+	Request *http.Request
+
+	hub  *Hub
+	conn *websocket.Conn
+	send chan *message
+	done chan struct{}
+	once sync.Once
+	code int
+
+	keys sync.Map
+
+	// rooms and uid are guarded by hub mutex
+	rooms map[string]struct{}
+	uid   string
+}
+
+// newSession returns a session of connection
+func newSession(hub *Hub, conn *websocket.Conn, r *http.Request) *Session {
+	s := &Session{
+		ID:      xstring.GenerateUUID(),
+		Request: r,
+		hub:     hub,
+		conn:    conn,
+		send:    make(chan *message, hub.config.SendBufferSize),
+		done:    make(chan struct{}),
+		code:    websocket.CloseNormalClosure,
+		rooms:   make(map[string]struct{}),
+	}
+
+	conn.SetReadLimit(hub.config.MaxMessageSize)
+	conn.SetReadDeadline(time.Now().Add(hub.config.PongTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(hub.config.PongTimeout))
+	})
+	return s
+}
+
+// ReadMessage reads a message from peer, it returns error once the session closed
+func (s *Session) ReadMessage() (messageType int, data []byte, err error) {
+	messageType, data, err = s.conn.ReadMessage()
+	if err != nil {
+		return
+	}
+
+	s.conn.SetReadDeadline(time.Now().Add(s.hub.config.PongTimeout))
+	metric.WebSocketMessageCounter.Inc(s.hub.path, "in")
+	return
+}
+
+// ReadJSON reads a json message from peer into v
+func (s *Session) ReadJSON(v interface{}) error {
+	_, data, err := s.ReadMessage()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// WriteMessage queues a message for sending. If the send buffer is full, the session is closed
+// and ErrSendBufferFull is returned.
+func (s *Session) WriteMessage(messageType int, data []byte) error {
+	select {
+	case <-s.done:
+		metric.WebSocketDropCounter.Inc(s.hub.path)
+		return ErrSessionClosed
+	default:
+	}
+
+	select {
+	case s.send <- &message{messageType: messageType, data: data}:
+		return nil
+	default:
+		metric.WebSocketDropCounter.Inc(s.hub.path)
+		log.Warn(s.Request.Context(), "websocket send buffer full, close session", log.String("session", s.ID))
+		s.close(websocket.ClosePolicyViolation)
+		return ErrSendBufferFull
+	}
+}
+
+// WriteText queues a text message for sending
+func (s *Session) WriteText(text string) error {
+	return s.WriteMessage(TextMessage, []byte(text))
+}
+
+// WriteJSON queues a json message for sending
+func (s *Session) WriteJSON(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return s.WriteMessage(TextMessage, data)
+}
+
+// Join joins room
+func (s *Session) Join(room string) {
+	s.hub.join(room, s)
+}
+
+// Leave leaves room
+func (s *Session) Leave(room string) {
+	s.hub.leave(room, s)
+}
+
+// Rooms returns rooms the session joined
+func (s *Session) Rooms() []string {
+	return s.hub.sessionRooms(s)
+}
+
+// BindUser binds session to user, messages sent to user are fan-out to all the sessions of user
+func (s *Session) BindUser(uid string) {
+	s.hub.bind(uid, s)
+}
+
+// Set stores a value of session
+func (s *Session) Set(key string, value interface{}) {
+	s.keys.Store(key, value)
+}
+
+// Get returns the value of session stored
+func (s *Session) Get(key string) (value interface{}, ok bool) {
+	return s.keys.Load(key)
+}
+
+// Done returns a channel closed once session closed
+func (s *Session) Done() <-chan struct{} {
+	return s.done
+}
+
+// Close closes session with normal closure, the queued messages are sent before closing
+func (s *Session) Close() {
+	s.close(websocket.CloseNormalClosure)
+}
+
+// close marks session closed with close code, the connection is closed by write pump
+func (s *Session) close(code int) {
+	s.once.Do(func() {
+		s.code = code
+		close(s.done)
+		s.hub.remove(s)
+	})
+}
+
+// writePump writes queued messages and pings peer, it closes the connection once session closed
+func (s *Session) writePump() {
+	ticker := time.NewTicker(s.hub.config.PingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case msg := <-s.send:
+			if err := s.write(msg.messageType, msg.data); err != nil {
+				s.close(websocket.CloseAbnormalClosure)
+				s.conn.Close()
+				return
+			}
+			metric.WebSocketMessageCounter.Inc(s.hub.path, "out")
+		case <-ticker.C:
+			if err := s.write(websocket.PingMessage, nil); err != nil {
+				s.close(websocket.CloseAbnormalClosure)
+				s.conn.Close()
+				return
+			}
+		case <-s.done:
+			s.flush()
+			s.closeConn(s.code)
+			return
+		}
+	}
+}
+
+// flush writes the queued messages before closing, it gives up on the first error
+func (s *Session) flush() {
+	for {
+		select {
+		case msg := <-s.send:
+			if err := s.write(msg.messageType, msg.data); err != nil {
+				return
+			}
+			metric.WebSocketMessageCounter.Inc(s.hub.path, "out")
+		default:
+			return
+		}
+	}
+}
+
+// write writes a message with write deadline
+func (s *Session) write(messageType int, data []byte) error {
+	s.conn.SetWriteDeadline(time.Now().Add(s.hub.config.WriteTimeout))
+	return s.conn.WriteMessage(messageType, data)
+}
+
+// closeConn sends close message and closes the connection
+func (s *Session) closeConn(code int) {
+	if code != websocket.CloseAbnormalClosure {
+		deadline := time.Now().Add(s.hub.config.WriteTimeout)
+		s.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, ""), deadline)
+	}
+	s.conn.Close()
+}
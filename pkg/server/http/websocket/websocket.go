@@ -19,15 +19,46 @@
 package websocket
 
 import (
+	"context"
 	"net/http"
+	"time"
 
 	"github.com/UnderTreeTech/waterdrop/pkg/log"
 	"github.com/gorilla/websocket"
 )
 
-// WebSocketHandler ws callback handler
+// WebSocketHandler ws callback handler, the connection is served by Conn of WebSocket
 type WebSocketHandler func(*WebSocket)
 
+// SessionHandler session callback handler, it's called in a goroutine for each session.
+// The session is closed once the handler returns.
+type SessionHandler func(*Session)
+
+// Config websocket config
+type Config struct {
+	// WriteTimeout write deadline of a message
+	WriteTimeout time.Duration
+	// PongTimeout the session is closed if no pong or message received in it
+	PongTimeout time.Duration
+	// PingInterval interval of sending ping, it must be less than PongTimeout
+	PingInterval time.Duration
+	// MaxMessageSize max bytes of a message read from peer
+	MaxMessageSize int64
+	// SendBufferSize max messages queued for sending of a session, the slow session is closed once exceeded
+	SendBufferSize int
+}
+
+// DefaultConfig default websocket config
+func DefaultConfig() *Config {
+	return &Config{
+		WriteTimeout:   10 * time.Second,
+		PongTimeout:    60 * time.Second,
+		PingInterval:   54 * time.Second,
+		MaxMessageSize: 64 * 1024,
+		SendBufferSize: 256,
+	}
+}
+
 // WebSocket ws definition
 type WebSocket struct {
 	Path    string
@@ -35,9 +66,12 @@ type WebSocket struct {
 
 	*websocket.Upgrader
 	*websocket.Conn
+
+	sessionHandler SessionHandler
+	hub            *Hub
 }
 
-// NewWebSocket returns a WebSocket instance
+// NewWebSocket returns a WebSocket instance, handler serves the connection by Conn of WebSocket
 func NewWebSocket(path string, handler WebSocketHandler) *WebSocket {
 	return &WebSocket{
 		Path:     path,
@@ -46,15 +80,68 @@ func NewWebSocket(path string, handler WebSocketHandler) *WebSocket {
 	}
 }
 
-// Upgrade upgrade http to WebSocket
+// NewSessionWebSocket returns a WebSocket instance serves each connection as a Session of its Hub,
+// sessions can join rooms and be broadcast to. Default config is used if config is nil
+func NewSessionWebSocket(path string, handler SessionHandler, config *Config) *WebSocket {
+	if config == nil {
+		config = DefaultConfig()
+	}
+
+	return &WebSocket{
+		Path:           path,
+		Upgrader:       &websocket.Upgrader{},
+		sessionHandler: handler,
+		hub:            newHub(path, config),
+	}
+}
+
+// Hub returns the hub of sessions, it's nil if WebSocket isn't created by NewSessionWebSocket
+func (ws *WebSocket) Hub() *Hub {
+	return ws.hub
+}
+
+// Upgrade upgrade http to WebSocket, and serves the connection until handler returns
 func (ws *WebSocket) Upgrade(w http.ResponseWriter, r *http.Request) {
+	if ws.hub == nil {
+		conn, err := ws.Upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			log.Error(r.Context(), "upgrade fail", log.String("error", err.Error()))
+			return
+		}
+		defer conn.Close()
+
+		ws.Conn = conn
+		ws.Handler(ws)
+		return
+	}
+
+	if ws.hub.isClosed() {
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	}
+
 	conn, err := ws.Upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.Error(r.Context(), "upgrade fail", log.String("error", err.Error()))
 		return
 	}
-	defer conn.Close()
 
-	ws.Conn = conn
-	ws.Handler(ws)
+	session := newSession(ws.hub, conn, r)
+	if !ws.hub.add(session) {
+		session.closeConn(websocket.CloseGoingAway)
+		return
+	}
+	defer ws.hub.done()
+
+	go session.writePump()
+	ws.sessionHandler(session)
+	session.Close()
+}
+
+// Close closes all the sessions with going away and waits for their handlers returned
+func (ws *WebSocket) Close(ctx context.Context) error {
+	if ws.hub == nil {
+		return nil
+	}
+	return ws.hub.Close(ctx)
 }
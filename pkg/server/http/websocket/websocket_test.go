@@ -0,0 +1,217 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package websocket
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/UnderTreeTech/waterdrop/pkg/log"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	defer log.New(nil).Sync()
+
+	code := m.Run()
+	os.Exit(code)
+}
+
+// serve starts a http server of ws and returns a dial function
+func serve(t *testing.T, ws *WebSocket) (func(query string) *websocket.Conn, func()) {
+	srv := httptest.NewServer(http.HandlerFunc(ws.Upgrade))
+	dial := func(query string) *websocket.Conn {
+		conn, _, err := websocket.DefaultDialer.Dial(wsURL(srv, ws)+"?"+query, nil)
+		assert.Nil(t, err)
+		return conn
+	}
+	return dial, srv.Close
+}
+
+// wsURL returns websocket url of server
+func wsURL(srv *httptest.Server, ws *WebSocket) string {
+	return "ws" + strings.TrimPrefix(srv.URL, "http") + ws.Path
+}
+
+// chat joins room and binds user of query, then echoes messages
+func chat(s *Session) {
+	if room := s.Request.URL.Query().Get("room"); room != "" {
+		s.Join(room)
+	}
+	if uid := s.Request.URL.Query().Get("uid"); uid != "" {
+		s.BindUser(uid)
+	}
+	s.WriteText("ready")
+
+	for {
+		mt, data, err := s.ReadMessage()
+		if err != nil {
+			return
+		}
+		s.WriteMessage(mt, data)
+	}
+}
+
+// read reads a text message with timeout
+func read(t *testing.T, conn *websocket.Conn) string {
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	_, data, err := conn.ReadMessage()
+	assert.Nil(t, err)
+	return string(data)
+}
+
+func TestWebSocket(t *testing.T) {
+	ws := NewWebSocket("/echo", func(ws *WebSocket) {
+		for {
+			mt, data, err := ws.ReadMessage()
+			if err != nil {
+				return
+			}
+			ws.WriteMessage(mt, data)
+		}
+	})
+	assert.Nil(t, ws.Hub())
+	dial, stop := serve(t, ws)
+	defer stop()
+
+	conn := dial("")
+	defer conn.Close()
+	assert.Nil(t, conn.WriteMessage(websocket.TextMessage, []byte("hello")))
+	assert.Equal(t, "hello", read(t, conn))
+	assert.Nil(t, ws.Close(context.Background()))
+}
+
+func TestSession(t *testing.T) {
+	ws := NewSessionWebSocket("/chat", chat, nil)
+	dial, stop := serve(t, ws)
+	defer stop()
+
+	a := dial("room=go&uid=1")
+	defer a.Close()
+	b := dial("room=go&uid=2")
+	defer b.Close()
+	c := dial("room=rust&uid=1")
+	defer c.Close()
+	for _, conn := range []*websocket.Conn{a, b, c} {
+		assert.Equal(t, "ready", read(t, conn))
+	}
+	assert.Equal(t, 3, ws.Hub().Len())
+
+	// sessions are isolated
+	assert.Nil(t, a.WriteMessage(websocket.TextMessage, []byte("ping a")))
+	assert.Nil(t, b.WriteMessage(websocket.TextMessage, []byte("ping b")))
+	assert.Equal(t, "ping a", read(t, a))
+	assert.Equal(t, "ping b", read(t, b))
+
+	ws.Hub().BroadcastRoom("go", websocket.TextMessage, []byte("gophers"))
+	assert.Equal(t, "gophers", read(t, a))
+	assert.Equal(t, "gophers", read(t, b))
+
+	ws.Hub().SendToUser("1", websocket.TextMessage, []byte("hi user 1"))
+	assert.Equal(t, "hi user 1", read(t, a))
+	assert.Equal(t, "hi user 1", read(t, c))
+
+	ws.Hub().Broadcast(websocket.TextMessage, []byte("all"))
+	for _, conn := range []*websocket.Conn{a, b, c} {
+		assert.Equal(t, "all", read(t, conn))
+	}
+
+	// sessions are removed once closed
+	c.Close()
+	assert.Eventually(t, func() bool { return ws.Hub().Len() == 2 }, time.Second, 10*time.Millisecond)
+}
+
+func TestMaxMessageSize(t *testing.T) {
+	config := DefaultConfig()
+	config.MaxMessageSize = 8
+	ws := NewSessionWebSocket("/limit", chat, config)
+	dial, stop := serve(t, ws)
+	defer stop()
+
+	conn := dial("")
+	defer conn.Close()
+	assert.Equal(t, "ready", read(t, conn))
+
+	assert.Nil(t, conn.WriteMessage(websocket.TextMessage, []byte("too large message")))
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	_, _, err := conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseMessageTooBig))
+}
+
+func TestPing(t *testing.T) {
+	config := DefaultConfig()
+	config.PingInterval = 20 * time.Millisecond
+	config.PongTimeout = 100 * time.Millisecond
+	ws := NewSessionWebSocket("/ping", chat, config)
+	dial, stop := serve(t, ws)
+	defer stop()
+
+	pings := make(chan struct{}, 10)
+	conn := dial("")
+	defer conn.Close()
+	conn.SetPingHandler(func(data string) error {
+		pings <- struct{}{}
+		return conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+	})
+
+	// the session is kept alive by pong beyond pong timeout
+	conn.SetReadDeadline(time.Now().Add(300 * time.Millisecond))
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+	time.Sleep(200 * time.Millisecond)
+	assert.True(t, len(pings) > 1)
+	assert.Equal(t, 1, ws.Hub().Len())
+}
+
+func TestClose(t *testing.T) {
+	ws := NewSessionWebSocket("/close", chat, nil)
+	srv := httptest.NewServer(http.HandlerFunc(ws.Upgrade))
+	defer srv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL(srv, ws), nil)
+	assert.Nil(t, err)
+	defer conn.Close()
+	assert.Equal(t, "ready", read(t, conn))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.Nil(t, ws.Close(ctx))
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	_, _, err = conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway))
+	assert.Equal(t, 0, ws.Hub().Len())
+
+	// new sessions are rejected once closed
+	_, resp, err := websocket.DefaultDialer.Dial(wsURL(srv, ws), nil)
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+}
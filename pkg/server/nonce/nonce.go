@@ -0,0 +1,77 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// Package nonce records request nonces to block replays of signed requests,
+// it's shared by http and rpc signature verification.
+package nonce
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/UnderTreeTech/waterdrop/pkg/database/redis"
+	"github.com/UnderTreeTech/waterdrop/pkg/utils/xcollection"
+)
+
+// Store records nonces to block replays
+type Store interface {
+	// Add records the nonce for ttl, it returns false if the nonce has been recorded
+	Add(ctx context.Context, nonce string, ttl time.Duration) (added bool, err error)
+}
+
+// redisStore records nonces in redis
+type redisStore struct {
+	redis  *redis.Redis
+	prefix string
+}
+
+// NewRedisStore returns a Store shared by all instances, nonces are recorded with the key prefix
+func NewRedisStore(r *redis.Redis, prefix string) Store {
+	return &redisStore{redis: r, prefix: prefix}
+}
+
+// Add records the nonce by SET NX PX
+func (s *redisStore) Add(ctx context.Context, nonce string, ttl time.Duration) (bool, error) {
+	return s.redis.SetNxEx(ctx, s.prefix+nonce, "1", int(ttl.Milliseconds()))
+}
+
+// memoryStore records nonces in a local lru cache
+type memoryStore struct {
+	cache *xcollection.LRUCache
+	mutex sync.Mutex
+}
+
+// NewMemoryStore returns a Store keeps at most size nonces in memory,
+// it only blocks replays to the same instance
+func NewMemoryStore(size int) Store {
+	return &memoryStore{cache: xcollection.NewLRU(size)}
+}
+
+// Add records the nonce with its expiration
+func (s *memoryStore) Add(ctx context.Context, nonce string, ttl time.Duration) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	if expire, ok := s.cache.Get(nonce); ok && now.Before(expire.(time.Time)) {
+		return false, nil
+	}
+	s.cache.Add(nonce, now.Add(ttl))
+	return true, nil
+}
@@ -0,0 +1,41 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package nonce
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore(2)
+	added, _ := store.Add(context.Background(), "a", time.Minute)
+	assert.True(t, added)
+	added, _ = store.Add(context.Background(), "a", time.Minute)
+	assert.False(t, added)
+
+	// expired nonce can be added again
+	added, _ = store.Add(context.Background(), "b", -time.Second)
+	assert.True(t, added)
+	added, _ = store.Add(context.Background(), "b", time.Minute)
+	assert.True(t, added)
+}
@@ -0,0 +1,96 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package balancer
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/UnderTreeTech/waterdrop/pkg/registry"
+
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/resolver"
+)
+
+// balancer names, set one of them to ClientConfig.Balancer to enable it.
+// All of them are color aware, see registry.WithColor for details.
+const (
+	// WeightedRoundRobin smooth weighted round robin balancer
+	WeightedRoundRobin = "waterdrop_wrr"
+	// ZoneAware prefer instances in the same zone, failover to others if none is ready
+	ZoneAware = "waterdrop_zone"
+	// P2C power of two choices balancer based on ewma latency and in-flight requests
+	P2C = "waterdrop_p2c"
+	// ConsistentHash ketama consistent hash balancer, hash key is set by WithHashKey
+	ConsistentHash = "waterdrop_consistent_hash"
+)
+
+// defaultWeight weight of instance without weight metadata
+const defaultWeight = 10
+
+var (
+	localZone string
+	zoneMutex sync.RWMutex
+)
+
+func init() {
+	balancer.Register(newBuilder(WeightedRoundRobin, &wrrPickerBuilder{}))
+	balancer.Register(newBuilder(ZoneAware, &zonePickerBuilder{}))
+	balancer.Register(&p2cBuilder{})
+	balancer.Register(newBuilder(ConsistentHash, &ketamaPickerBuilder{}))
+}
+
+// newBuilder returns a color aware balancer builder with the picker builder
+func newBuilder(name string, pb base.PickerBuilder) balancer.Builder {
+	return base.NewBalancerBuilder(name, newColorPickerBuilder(pb), base.Config{HealthCheck: true})
+}
+
+// SetZone set the zone(IDC) where current process runs, zone aware balancer
+// prefer instances whose zone metadata equals to it
+func SetZone(zone string) {
+	zoneMutex.Lock()
+	localZone = zone
+	zoneMutex.Unlock()
+}
+
+// GetZone returns the zone where current process runs
+func GetZone() string {
+	zoneMutex.RLock()
+	defer zoneMutex.RUnlock()
+	return localZone
+}
+
+// metadata returns the string metadata attached to the resolved address
+func metadata(addr resolver.Address, key string) string {
+	if val, ok := addr.Attributes.Value(key).(string); ok {
+		return val
+	}
+	return ""
+}
+
+// weight returns the weight attached to the resolved address, it returns
+// defaultWeight if weight metadata is missing or invalid
+func weight(addr resolver.Address) int {
+	w, err := strconv.Atoi(metadata(addr, registry.MetaWeight))
+	if err != nil || w <= 0 {
+		return defaultWeight
+	}
+	return w
+}
@@ -0,0 +1,151 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package balancer
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/UnderTreeTech/waterdrop/pkg/registry"
+
+	"github.com/UnderTreeTech/waterdrop/tests/proto/demo"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/stretchr/testify/assert"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/attributes"
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/resolver/manual"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// backends in-process grpc servers listen on bufconn, keyed by address
+type backends map[string]*bufconn.Listener
+
+// startBackends start a demo server for each address, the server replies its address
+func startBackends(t *testing.T, delays map[string]time.Duration) (backends, func()) {
+	bs := make(backends)
+	servers := make([]*grpc.Server, 0, len(delays))
+	for addr, delay := range delays {
+		lis := bufconn.Listen(1024 * 1024)
+		srv := grpc.NewServer()
+		demo.RegisterDemoServer(srv, &service{addr: addr, delay: delay})
+		go srv.Serve(lis)
+
+		bs[addr] = lis
+		servers = append(servers, srv)
+	}
+
+	return bs, func() {
+		for _, srv := range servers {
+			srv.Stop()
+		}
+	}
+}
+
+// dial dial the backends in memory with the balancer
+func dial(t *testing.T, policy string, bs backends, addrs []resolver.Address) *grpc.ClientConn {
+	r := manual.NewBuilderWithScheme("waterdrop")
+	r.InitialState(resolver.State{Addresses: addrs})
+
+	cc, err := grpc.Dial(
+		r.Scheme()+":///demo",
+		grpc.WithResolvers(r),
+		grpc.WithInsecure(),
+		grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+			return bs[addr].Dial()
+		}),
+		grpc.WithDefaultServiceConfig(`{"loadBalancingPolicy":"`+policy+`"}`),
+	)
+	assert.Nil(t, err)
+	return cc
+}
+
+// address returns a resolved address with metadata
+func address(addr string, kvs ...string) resolver.Address {
+	attrs := attributes.New("scheme", "grpc")
+	for i := 0; i+1 < len(kvs); i += 2 {
+		attrs = attrs.WithValues(kvs[i], kvs[i+1])
+	}
+	return resolver.Address{Addr: addr, Attributes: attrs}
+}
+
+// waitReady call until all the expected backends have replied
+func waitReady(t *testing.T, client demo.DemoClient, expected ...string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	seen := make(map[string]struct{})
+	for len(seen) < len(expected) {
+		reply, err := client.SayHelloURL(ctx, &demo.HelloReq{}, grpc.WaitForReady(true))
+		if err != nil {
+			t.Fatalf("wait backends ready fail, error %s", err.Error())
+		}
+		seen[reply.Content] = struct{}{}
+	}
+}
+
+// call make n calls and count replies of each backend
+func call(t *testing.T, client demo.DemoClient, n int) map[string]int {
+	counts := make(map[string]int)
+	for i := 0; i < n; i++ {
+		reply, err := client.SayHelloURL(context.Background(), &demo.HelloReq{})
+		assert.Nil(t, err)
+		counts[reply.GetContent()]++
+	}
+	return counts
+}
+
+func TestWeight(t *testing.T) {
+	assert.Equal(t, 5, weight(address("a", registry.MetaWeight, "5")))
+	assert.Equal(t, defaultWeight, weight(address("a")))
+	assert.Equal(t, defaultWeight, weight(address("a", registry.MetaWeight, "-1")))
+	assert.Equal(t, defaultWeight, weight(address("a", registry.MetaWeight, "heavy")))
+}
+
+func TestZone(t *testing.T) {
+	defer SetZone("")
+
+	SetZone("sh-1")
+	assert.Equal(t, "sh-1", GetZone())
+}
+
+// subConn a fake SubConn for picker test
+type subConn struct {
+	balancer.SubConn
+	addr string
+}
+
+type service struct {
+	addr  string
+	delay time.Duration
+}
+
+func (s *service) SayHello(ctx context.Context, req *demo.HelloReq) (reply *emptypb.Empty, err error) {
+	return &emptypb.Empty{}, nil
+}
+
+func (s *service) SayHelloURL(ctx context.Context, req *demo.HelloReq) (reply *demo.HelloResp, err error) {
+	time.Sleep(s.delay)
+	return &demo.HelloResp{Content: s.addr}, nil
+}
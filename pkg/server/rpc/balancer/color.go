@@ -0,0 +1,78 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package balancer
+
+import (
+	"github.com/UnderTreeTech/waterdrop/pkg/registry"
+
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+)
+
+// colorPickerBuilder groups ready instances by their color metadata and
+// builds a picker for each group with the underlying picker builder
+type colorPickerBuilder struct {
+	builder base.PickerBuilder
+}
+
+// newColorPickerBuilder returns a color aware picker builder
+func newColorPickerBuilder(builder base.PickerBuilder) *colorPickerBuilder {
+	return &colorPickerBuilder{builder: builder}
+}
+
+// Build returns a color picker. Instances without color make up the default group,
+// if there is none of them, the default group falls back to all ready instances.
+func (b *colorPickerBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
+	if len(info.ReadySCs) == 0 {
+		return base.NewErrPicker(balancer.ErrNoSubConnAvailable)
+	}
+
+	groups := make(map[string]map[balancer.SubConn]base.SubConnInfo)
+	for sc, sci := range info.ReadySCs {
+		color := metadata(sci.Address, registry.MetaColor)
+		if _, ok := groups[color]; !ok {
+			groups[color] = make(map[balancer.SubConn]base.SubConnInfo)
+		}
+		groups[color][sc] = sci
+	}
+
+	if _, ok := groups[""]; !ok {
+		groups[""] = info.ReadySCs
+	}
+
+	picker := &colorPicker{pickers: make(map[string]balancer.Picker, len(groups))}
+	for color, scs := range groups {
+		picker.pickers[color] = b.builder.Build(base.PickerBuildInfo{ReadySCs: scs})
+	}
+	return picker
+}
+
+// colorPicker routes requests to the group with the same color as the request,
+// requests without color or whose color group is not ready go to the default group
+type colorPicker struct {
+	pickers map[string]balancer.Picker
+}
+
+// Pick picks an instance from the group of request color
+func (p *colorPicker) Pick(info balancer.PickInfo) (balancer.PickResult, error) {
+	if picker, ok := p.pickers[registry.ColorFromContext(info.Ctx)]; ok {
+		return picker.Pick(info)
+	}
+	return p.pickers[""].Pick(info)
+}
@@ -0,0 +1,62 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package balancer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/UnderTreeTech/waterdrop/pkg/registry"
+	"github.com/UnderTreeTech/waterdrop/tests/proto/demo"
+
+	"github.com/stretchr/testify/assert"
+
+	"google.golang.org/grpc/resolver"
+)
+
+func TestColor(t *testing.T) {
+	bs, stop := startBackends(t, map[string]time.Duration{"color-1": 0, "color-2": 0, "color-canary": 0})
+	defer stop()
+
+	cc := dial(t, WeightedRoundRobin, bs, []resolver.Address{
+		address("color-1"),
+		address("color-2"),
+		address("color-canary", registry.MetaColor, "canary"),
+	})
+	defer cc.Close()
+
+	client := demo.NewDemoClient(cc)
+	waitReady(t, client, "color-1", "color-2")
+	ctx := registry.WithColor(context.Background(), "canary")
+	reply, err := client.SayHelloURL(ctx, &demo.HelloReq{})
+	assert.Nil(t, err)
+	assert.Equal(t, "color-canary", reply.Content)
+
+	// requests without color never go to canary instances
+	counts := call(t, client, 10)
+	assert.Equal(t, 5, counts["color-1"])
+	assert.Equal(t, 5, counts["color-2"])
+
+	// requests with unknown color fall back to default instances
+	ctx = registry.WithColor(context.Background(), "blue")
+	reply, err = client.SayHelloURL(ctx, &demo.HelloReq{})
+	assert.Nil(t, err)
+	assert.NotEqual(t, "color-canary", reply.Content)
+}
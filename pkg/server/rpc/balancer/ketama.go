@@ -0,0 +1,157 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package balancer
+
+import (
+	"context"
+	"encoding/binary"
+	"math/rand"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/UnderTreeTech/waterdrop/pkg/utils/xcrypto"
+
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+)
+
+// pointsPerWeight virtual nodes of per weight on the ring, a node with
+// default weight owns 160 virtual nodes as ketama does
+const pointsPerWeight = 16
+
+type hashKey struct{}
+
+// WithHashKey returns a new context carries the hash key, consistent hash
+// balancer routes requests with the same key to the same instance
+func WithHashKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, hashKey{}, key)
+}
+
+// HashKeyFromContext returns the hash key carried by ctx
+func HashKeyFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+
+	key, _ := ctx.Value(hashKey{}).(string)
+	return key
+}
+
+// ring ketama consistent hash ring. Virtual nodes are derived from the node
+// address only, so adding or removing a node just moves the keys owned by it.
+type ring struct {
+	points []uint32
+	nodes  map[uint32]string
+}
+
+// newRing returns a ring of nodes, the key of nodes is address and the value is weight
+func newRing(nodes map[string]int) *ring {
+	r := &ring{
+		points: make([]uint32, 0),
+		nodes:  make(map[uint32]string),
+	}
+
+	for addr, weight := range nodes {
+		for i := 0; i < weight*pointsPerWeight/4; i++ {
+			digest := xcrypto.Hash(addr+"-"+strconv.Itoa(i), xcrypto.MD5)
+			// every md5 digest generates 4 virtual nodes
+			for j := 0; j < 4; j++ {
+				point := binary.LittleEndian.Uint32(digest[j*4 : j*4+4])
+				// the smaller address wins on collision, so the ring is
+				// deterministic regardless of the order of nodes
+				if owner, ok := r.nodes[point]; !ok {
+					r.points = append(r.points, point)
+				} else if owner < addr {
+					continue
+				}
+				r.nodes[point] = addr
+			}
+		}
+	}
+
+	sort.Slice(r.points, func(i, j int) bool {
+		return r.points[i] < r.points[j]
+	})
+	return r
+}
+
+// get returns the address of node which owns the key
+func (r *ring) get(key string) string {
+	if len(r.points) == 0 {
+		return ""
+	}
+
+	digest := xcrypto.Hash(key, xcrypto.MD5)
+	hash := binary.LittleEndian.Uint32(digest[:4])
+	i := sort.Search(len(r.points), func(i int) bool {
+		return r.points[i] >= hash
+	})
+	if i == len(r.points) {
+		i = 0
+	}
+	return r.nodes[r.points[i]]
+}
+
+type ketamaPickerBuilder struct{}
+
+// Build returns a consistent hash picker over the ready instances
+func (*ketamaPickerBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
+	if len(info.ReadySCs) == 0 {
+		return base.NewErrPicker(balancer.ErrNoSubConnAvailable)
+	}
+
+	weights := make(map[string]int, len(info.ReadySCs))
+	picker := &ketamaPicker{
+		subConns: make(map[string]balancer.SubConn, len(info.ReadySCs)),
+		addrs:    make([]string, 0, len(info.ReadySCs)),
+		r:        rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	for sc, sci := range info.ReadySCs {
+		weights[sci.Address.Addr] = weight(sci.Address)
+		picker.subConns[sci.Address.Addr] = sc
+		picker.addrs = append(picker.addrs, sci.Address.Addr)
+	}
+
+	picker.ring = newRing(weights)
+	return picker
+}
+
+// ketamaPicker consistent hash picker, requests without hash key are picked randomly
+type ketamaPicker struct {
+	ring     *ring
+	subConns map[string]balancer.SubConn
+	addrs    []string
+	r        *rand.Rand
+	mutex    sync.Mutex
+}
+
+// Pick picks the instance which owns the hash key of request
+func (p *ketamaPicker) Pick(info balancer.PickInfo) (balancer.PickResult, error) {
+	key := HashKeyFromContext(info.Ctx)
+	if key == "" {
+		p.mutex.Lock()
+		addr := p.addrs[p.r.Intn(len(p.addrs))]
+		p.mutex.Unlock()
+		return balancer.PickResult{SubConn: p.subConns[addr]}, nil
+	}
+
+	return balancer.PickResult{SubConn: p.subConns[p.ring.get(key)]}, nil
+}
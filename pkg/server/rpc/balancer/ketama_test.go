@@ -0,0 +1,92 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package balancer
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/UnderTreeTech/waterdrop/tests/proto/demo"
+
+	"github.com/stretchr/testify/assert"
+
+	"google.golang.org/grpc/resolver"
+)
+
+func TestHashKey(t *testing.T) {
+	assert.Equal(t, "", HashKeyFromContext(context.Background()))
+	assert.Equal(t, "user", HashKeyFromContext(WithHashKey(context.Background(), "user")))
+}
+
+func TestRing(t *testing.T) {
+	nodes := map[string]int{"a": 10, "b": 10, "c": 10, "d": 10, "e": 10}
+	r := newRing(nodes)
+	assert.Equal(t, 5*10*pointsPerWeight, len(r.points))
+
+	owners := make(map[string]string)
+	for i := 0; i < 1000; i++ {
+		key := strconv.Itoa(i)
+		owners[key] = r.get(key)
+	}
+
+	// keys owned by the remaining nodes stay put after removing a node
+	delete(nodes, "c")
+	r = newRing(nodes)
+	for key, owner := range owners {
+		if owner != "c" {
+			assert.Equal(t, owner, r.get(key))
+		}
+	}
+
+	// only keys moved to the new node change their owner after adding a node
+	nodes["c"] = 10
+	nodes["f"] = 10
+	r = newRing(nodes)
+	for key, owner := range owners {
+		if current := r.get(key); current != "f" {
+			assert.Equal(t, owner, current)
+		}
+	}
+
+	assert.Equal(t, "", newRing(nil).get("key"))
+}
+
+func TestConsistentHash(t *testing.T) {
+	bs, stop := startBackends(t, map[string]time.Duration{"hash-1": 0, "hash-2": 0, "hash-3": 0})
+	defer stop()
+
+	cc := dial(t, ConsistentHash, bs, []resolver.Address{address("hash-1"), address("hash-2"), address("hash-3")})
+	defer cc.Close()
+
+	client := demo.NewDemoClient(cc)
+	waitReady(t, client, "hash-1", "hash-2", "hash-3")
+
+	for i := 0; i < 10; i++ {
+		ctx := WithHashKey(context.Background(), "user-"+strconv.Itoa(i))
+		first, err := client.SayHelloURL(ctx, &demo.HelloReq{})
+		assert.Nil(t, err)
+		for j := 0; j < 5; j++ {
+			reply, err := client.SayHelloURL(ctx, &demo.HelloReq{})
+			assert.Nil(t, err)
+			assert.Equal(t, first.Content, reply.Content)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package balancer
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/connectivity"
+)
+
+const (
+	// decayTime the time window of ewma latency decay
+	decayTime = int64(600 * time.Millisecond)
+	// forcePick a node will be picked forcibly if it is not picked for a long time,
+	// so that its latency can be refreshed
+	forcePick = int64(3 * time.Second)
+)
+
+// p2cBuilder builds a p2c balancer for each ClientConn, so that node stats
+// survive picker rebuilding and are not shared between ClientConns
+type p2cBuilder struct{}
+
+// Build returns a p2c balancer
+func (*p2cBuilder) Build(cc balancer.ClientConn, opts balancer.BuildOptions) balancer.Balancer {
+	pb := &p2cPickerBuilder{nodes: make(map[balancer.SubConn]*p2cNode)}
+	return &p2cBalancer{
+		Balancer: newBuilder(P2C, pb).Build(cc, opts),
+		pb:       pb,
+	}
+}
+
+// Name returns p2c balancer name
+func (*p2cBuilder) Name() string {
+	return P2C
+}
+
+// p2cBalancer removes stats of node once its SubConn is shutdown
+type p2cBalancer struct {
+	balancer.Balancer
+	pb *p2cPickerBuilder
+}
+
+// UpdateSubConnState removes stats of shutdown SubConn and passes the state to base balancer
+func (b *p2cBalancer) UpdateSubConnState(sc balancer.SubConn, state balancer.SubConnState) {
+	if state.ConnectivityState == connectivity.Shutdown {
+		delete(b.pb.nodes, sc)
+	}
+	b.Balancer.UpdateSubConnState(sc, state)
+}
+
+// p2cPickerBuilder keeps stats of nodes, it's only accessed by the balancer goroutine
+type p2cPickerBuilder struct {
+	nodes map[balancer.SubConn]*p2cNode
+}
+
+// Build returns a p2c picker, stats of nodes are kept until their SubConns are shutdown
+func (pb *p2cPickerBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
+	if len(info.ReadySCs) == 0 {
+		return base.NewErrPicker(balancer.ErrNoSubConnAvailable)
+	}
+
+	picker := &p2cPicker{
+		nodes: make([]*p2cNode, 0, len(info.ReadySCs)),
+		r:     rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	for sc := range info.ReadySCs {
+		node, ok := pb.nodes[sc]
+		if !ok {
+			node = &p2cNode{sc: sc, stamp: time.Now().UnixNano()}
+			pb.nodes[sc] = node
+		}
+		picker.nodes = append(picker.nodes, node)
+	}
+	return picker
+}
+
+// p2cNode a ready SubConn with its load stats
+type p2cNode struct {
+	sc balancer.SubConn
+	// lag ewma latency in nanoseconds
+	lag int64
+	// inflight requests in processing
+	inflight int64
+	// stamp last time of lag updated
+	stamp int64
+	// picked last time of the node picked
+	picked int64
+}
+
+// load returns the load of node, the larger the busier
+func (n *p2cNode) load() int64 {
+	lag := atomic.LoadInt64(&n.lag) + 1
+	return lag * (atomic.LoadInt64(&n.inflight) + 1)
+}
+
+// observe updates ewma latency of node by the latest request latency,
+// the longer the time since last update, the smaller the weight of history
+func (n *p2cNode) observe(latency int64) {
+	now := time.Now().UnixNano()
+	td := now - atomic.SwapInt64(&n.stamp, now)
+	if td < 0 {
+		td = 0
+	}
+
+	w := math.Exp(-float64(td) / float64(decayTime))
+	lag := atomic.LoadInt64(&n.lag)
+	if lag == 0 {
+		w = 0
+	}
+	atomic.StoreInt64(&n.lag, int64(float64(lag)*w+float64(latency)*(1-w)))
+}
+
+// p2cPicker power of two choices picker
+type p2cPicker struct {
+	nodes []*p2cNode
+	r     *rand.Rand
+	mutex sync.Mutex
+}
+
+// Pick picks two nodes randomly and chooses the one with lower load
+func (p *p2cPicker) Pick(balancer.PickInfo) (balancer.PickResult, error) {
+	if len(p.nodes) == 1 {
+		return p.pick(p.nodes[0]), nil
+	}
+
+	p.mutex.Lock()
+	a := p.r.Intn(len(p.nodes))
+	b := p.r.Intn(len(p.nodes) - 1)
+	p.mutex.Unlock()
+	if b >= a {
+		b++
+	}
+
+	chosen, unchosen := p.nodes[a], p.nodes[b]
+	if chosen.load() > unchosen.load() {
+		chosen, unchosen = unchosen, chosen
+	}
+
+	// pick the unchosen node forcibly if it has not been picked for a long time
+	if time.Now().UnixNano()-atomic.LoadInt64(&unchosen.picked) > forcePick {
+		chosen = unchosen
+	}
+	return p.pick(chosen), nil
+}
+
+// pick marks the node picked and returns the pick result
+func (p *p2cPicker) pick(node *p2cNode) balancer.PickResult {
+	start := time.Now().UnixNano()
+	atomic.StoreInt64(&node.picked, start)
+	atomic.AddInt64(&node.inflight, 1)
+
+	return balancer.PickResult{
+		SubConn: node.sc,
+		Done: func(balancer.DoneInfo) {
+			atomic.AddInt64(&node.inflight, -1)
+			node.observe(time.Now().UnixNano() - start)
+		},
+	}
+}
@@ -0,0 +1,57 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package balancer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/UnderTreeTech/waterdrop/tests/proto/demo"
+
+	"github.com/stretchr/testify/assert"
+
+	"google.golang.org/grpc/resolver"
+)
+
+func TestP2CNode(t *testing.T) {
+	node := &p2cNode{stamp: time.Now().UnixNano()}
+	node.observe(int64(10 * time.Millisecond))
+	assert.Equal(t, int64(10*time.Millisecond), node.lag)
+
+	node.observe(int64(20 * time.Millisecond))
+	assert.True(t, node.lag > int64(10*time.Millisecond))
+	assert.True(t, node.lag < int64(20*time.Millisecond))
+
+	node.inflight = 1
+	assert.Equal(t, (node.lag+1)*2, node.load())
+}
+
+func TestP2C(t *testing.T) {
+	bs, stop := startBackends(t, map[string]time.Duration{"p2c-fast": 0, "p2c-slow": 20 * time.Millisecond})
+	defer stop()
+
+	cc := dial(t, P2C, bs, []resolver.Address{address("p2c-fast"), address("p2c-slow")})
+	defer cc.Close()
+
+	client := demo.NewDemoClient(cc)
+	waitReady(t, client, "p2c-fast", "p2c-slow")
+
+	counts := call(t, client, 100)
+	assert.True(t, counts["p2c-fast"] > counts["p2c-slow"])
+}
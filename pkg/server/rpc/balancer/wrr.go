@@ -0,0 +1,86 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package balancer
+
+import (
+	"sort"
+	"sync"
+
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+)
+
+// weightedNode a ready SubConn with its weight
+type weightedNode struct {
+	sc            balancer.SubConn
+	addr          string
+	weight        int
+	currentWeight int
+}
+
+type wrrPickerBuilder struct{}
+
+// Build returns a smooth weighted round robin picker
+func (*wrrPickerBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
+	if len(info.ReadySCs) == 0 {
+		return base.NewErrPicker(balancer.ErrNoSubConnAvailable)
+	}
+
+	nodes := make([]*weightedNode, 0, len(info.ReadySCs))
+	for sc, sci := range info.ReadySCs {
+		nodes = append(nodes, &weightedNode{sc: sc, addr: sci.Address.Addr, weight: weight(sci.Address)})
+	}
+	return newWrrPicker(nodes)
+}
+
+// wrrPicker smooth weighted round robin picker, the algorithm borrowed from nginx
+type wrrPicker struct {
+	nodes []*weightedNode
+	mutex sync.Mutex
+}
+
+// newWrrPicker returns a wrrPicker with nodes sorted by address
+func newWrrPicker(nodes []*weightedNode) *wrrPicker {
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].addr < nodes[j].addr
+	})
+	return &wrrPicker{nodes: nodes}
+}
+
+// Pick picks the node with the largest current weight, then decreases
+// its current weight by the total weight
+func (p *wrrPicker) Pick(balancer.PickInfo) (balancer.PickResult, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	var (
+		total int
+		best  *weightedNode
+	)
+	for _, node := range p.nodes {
+		node.currentWeight += node.weight
+		total += node.weight
+		if best == nil || node.currentWeight > best.currentWeight {
+			best = node
+		}
+	}
+
+	best.currentWeight -= total
+	return balancer.PickResult{SubConn: best.sc}, nil
+}
@@ -0,0 +1,66 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package balancer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/UnderTreeTech/waterdrop/pkg/registry"
+	"github.com/UnderTreeTech/waterdrop/tests/proto/demo"
+
+	"github.com/stretchr/testify/assert"
+
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/resolver"
+)
+
+func TestWrrPicker(t *testing.T) {
+	picker := newWrrPicker([]*weightedNode{
+		{sc: &subConn{addr: "a"}, addr: "a", weight: 5},
+		{sc: &subConn{addr: "b"}, addr: "b", weight: 1},
+		{sc: &subConn{addr: "c"}, addr: "c", weight: 1},
+	})
+
+	// smooth weighted round robin sequence: a a b a c a a
+	expected := []string{"a", "a", "b", "a", "c", "a", "a"}
+	for _, addr := range expected {
+		result, err := picker.Pick(balancer.PickInfo{})
+		assert.Nil(t, err)
+		assert.Equal(t, addr, result.SubConn.(*subConn).addr)
+	}
+}
+
+func TestWeightedRoundRobin(t *testing.T) {
+	bs, stop := startBackends(t, map[string]time.Duration{"wrr-1": 0, "wrr-2": 0})
+	defer stop()
+
+	cc := dial(t, WeightedRoundRobin, bs, []resolver.Address{
+		address("wrr-1", registry.MetaWeight, "3"),
+		address("wrr-2", registry.MetaWeight, "1"),
+	})
+	defer cc.Close()
+
+	client := demo.NewDemoClient(cc)
+	waitReady(t, client, "wrr-1", "wrr-2")
+
+	counts := call(t, client, 400)
+	assert.Equal(t, 300, counts["wrr-1"])
+	assert.Equal(t, 100, counts["wrr-2"])
+}
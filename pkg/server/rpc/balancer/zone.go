@@ -0,0 +1,52 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package balancer
+
+import (
+	"github.com/UnderTreeTech/waterdrop/pkg/registry"
+
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+)
+
+type zonePickerBuilder struct{}
+
+// Build returns a weighted round robin picker over the ready instances in local zone.
+// If there is no ready instance in local zone, it fails over to all the ready instances.
+func (*zonePickerBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
+	if len(info.ReadySCs) == 0 {
+		return base.NewErrPicker(balancer.ErrNoSubConnAvailable)
+	}
+
+	zone := GetZone()
+	all := make([]*weightedNode, 0, len(info.ReadySCs))
+	local := make([]*weightedNode, 0, len(info.ReadySCs))
+	for sc, sci := range info.ReadySCs {
+		node := &weightedNode{sc: sc, addr: sci.Address.Addr, weight: weight(sci.Address)}
+		all = append(all, node)
+		if zone != "" && metadata(sci.Address, registry.MetaZone) == zone {
+			local = append(local, node)
+		}
+	}
+
+	if len(local) > 0 {
+		return newWrrPicker(local)
+	}
+	return newWrrPicker(all)
+}
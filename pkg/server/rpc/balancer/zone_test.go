@@ -0,0 +1,76 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package balancer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/UnderTreeTech/waterdrop/pkg/registry"
+	"github.com/UnderTreeTech/waterdrop/tests/proto/demo"
+
+	"github.com/stretchr/testify/assert"
+
+	"google.golang.org/grpc/resolver"
+)
+
+func TestZoneAware(t *testing.T) {
+	SetZone("sh-1")
+	defer SetZone("")
+
+	bs, stop := startBackends(t, map[string]time.Duration{"zone-1": 0, "zone-2": 0, "zone-3": 0})
+	defer stop()
+
+	cc := dial(t, ZoneAware, bs, []resolver.Address{
+		address("zone-1", registry.MetaZone, "sh-1"),
+		address("zone-2", registry.MetaZone, "sh-1"),
+		address("zone-3", registry.MetaZone, "sh-2"),
+	})
+	defer cc.Close()
+
+	client := demo.NewDemoClient(cc)
+	waitReady(t, client, "zone-1", "zone-2")
+
+	counts := call(t, client, 100)
+	assert.Equal(t, 50, counts["zone-1"])
+	assert.Equal(t, 50, counts["zone-2"])
+	assert.Equal(t, 0, counts["zone-3"])
+}
+
+func TestZoneAwareFailover(t *testing.T) {
+	SetZone("sh-1")
+	defer SetZone("")
+
+	bs, stop := startBackends(t, map[string]time.Duration{"failover-1": 0, "failover-2": 0})
+	defer stop()
+
+	// the only instance in local zone is down
+	bs["failover-1"].Close()
+	cc := dial(t, ZoneAware, bs, []resolver.Address{
+		address("failover-1", registry.MetaZone, "sh-1"),
+		address("failover-2", registry.MetaZone, "sh-2"),
+	})
+	defer cc.Close()
+
+	client := demo.NewDemoClient(cc)
+	waitReady(t, client, "failover-2")
+
+	counts := call(t, client, 10)
+	assert.Equal(t, 10, counts["failover-2"])
+}
@@ -24,6 +24,8 @@ import (
 
 	"github.com/UnderTreeTech/waterdrop/pkg/server/rpc/config"
 
+	"github.com/UnderTreeTech/waterdrop/pkg/server/rpc/balancer"
+
 	"github.com/UnderTreeTech/waterdrop/pkg/server/rpc/metadata"
 
 	"github.com/UnderTreeTech/waterdrop/pkg/server/rpc/interceptors"
@@ -45,8 +47,8 @@ type Client struct {
 	unaryInterceptors []grpc.UnaryClientInterceptor
 }
 
-// New returns a Client instance
-func New(config *config.ClientConfig) *Client {
+// New returns a Client instance, opts are appended to the default dial options
+func New(config *config.ClientConfig, opts ...grpc.DialOption) *Client {
 	cli := &Client{
 		config:   config,
 		breakers: breaker.NewBreakerGroup(),
@@ -77,6 +79,7 @@ func New(config *config.ClientConfig) *Client {
 		interceptors.RecoveryForUnaryClient(cli.config),
 		interceptors.TraceForUnaryClient(),
 		interceptors.LoggerForUnaryClient(cli.config),
+		interceptors.MetricForUnaryClient(),
 		interceptors.GoogleSREBreaker(cli.breakers),
 	)
 
@@ -90,6 +93,7 @@ func New(config *config.ClientConfig) *Client {
 		grpc.WithDefaultServiceConfig(`{"loadBalancingPolicy":"`+config.Balancer+`"}`),
 		cli.WithUnaryServerChain(),
 	)
+	cli.clientOptions = append(cli.clientOptions, opts...)
 
 	cc, err := grpc.DialContext(ctx, config.Target, cli.clientOptions...)
 	if err != nil {
@@ -153,3 +157,9 @@ func (c *Client) GetConn() *grpc.ClientConn {
 func (c *Client) GetBreakers() *breaker.BreakerGroup {
 	return c.breakers
 }
+
+// WithHashKey returns a new context carries the hash key of request, requests with
+// the same key are routed to the same instance by consistent hash balancer
+func WithHashKey(ctx context.Context, key string) context.Context {
+	return balancer.WithHashKey(ctx, key)
+}
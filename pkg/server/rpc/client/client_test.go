@@ -30,6 +30,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/UnderTreeTech/waterdrop/pkg/server/rpc/balancer"
 	"github.com/UnderTreeTech/waterdrop/pkg/server/rpc/config"
 	"github.com/UnderTreeTech/waterdrop/pkg/server/rpc/server"
 )
@@ -89,6 +90,12 @@ func TestDialTimeout(t *testing.T) {
 	demo.NewDemoClient(client.GetConn())
 }
 
+// TestWithHashKey test hash key carried by context
+func TestWithHashKey(t *testing.T) {
+	ctx := WithHashKey(context.Background(), "waterdrop")
+	assert.Equal(t, "waterdrop", balancer.HashKeyFromContext(ctx))
+}
+
 type service struct{}
 
 func (s *service) SayHello(ctx context.Context, req *demo.HelloReq) (reply *emptypb.Empty, err error) {
@@ -64,11 +64,15 @@ type ClientConfig struct {
 	// Block dial mode: sync or async
 	Block bool
 	// Balancer client balancer, default round robbin
+	// waterdrop_wrr, waterdrop_zone, waterdrop_p2c and waterdrop_consistent_hash are also available
 	Balancer string
 	// Target rpc server endpoint
 	Target string
 	// Timeout rpc request timeout
 	Timeout time.Duration
+	// Timeouts per method request timeout, keyed by method name or full method,
+	// it overrides Timeout
+	Timeouts map[string]time.Duration
 	// GRPC ClientParameters
 	KeepAliveInterval time.Duration
 	KeepAliveTimeout  time.Duration
@@ -93,3 +97,33 @@ func DefaultClientConfig() *ClientConfig {
 		KeepAliveTimeout:  20 * time.Second,
 	}
 }
+
+// JWTConfig jwt authentication config
+type JWTConfig struct {
+	// Algorithm sign algorithm, HS256 or RS256
+	Algorithm string
+	// Secret HS256 sign secret
+	Secret string
+	// PublicKeyPath RS256 public key, server verifies token with it
+	PublicKeyPath string
+	// PrivateKeyPath RS256 private key, client signs token with it
+	PrivateKeyPath string
+	// Issuer token issuer, server verifies it if not empty
+	Issuer string
+	// Expire lifetime of the token signed by client
+	Expire time.Duration
+	// Skip full methods escape authentication, like /package.Service/Method
+	Skip []string
+}
+
+// SignatureConfig appkey signature authentication config
+type SignatureConfig struct {
+	// Key client appkey
+	Key string
+	// Secret client signature secret
+	Secret string
+	// Expire max time gap between request timestamp and server time
+	Expire time.Duration
+	// Skip full methods escape authentication, like /package.Service/Method
+	Skip []string
+}
@@ -0,0 +1,300 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package interceptors
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/UnderTreeTech/waterdrop/pkg/log"
+	"github.com/UnderTreeTech/waterdrop/pkg/server/rpc/config"
+	"github.com/UnderTreeTech/waterdrop/pkg/status"
+	"github.com/UnderTreeTech/waterdrop/pkg/utils/xcrypto"
+	"github.com/UnderTreeTech/waterdrop/pkg/utils/xslice"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// HS256 HMAC-SHA256 jwt sign algorithm
+	HS256 = "HS256"
+	// RS256 RSA-SHA256 jwt sign algorithm
+	RS256 = "RS256"
+
+	authorizationKey = "authorization"
+	bearerPrefix     = "Bearer "
+)
+
+var (
+	// ErrInvalidToken indicates the token is malformed or its signature mismatch
+	ErrInvalidToken = errors.New("invalid token")
+	// ErrTokenExpired indicates the token is expired or not valid yet
+	ErrTokenExpired = errors.New("token expired")
+	// ErrUnsupportedAlgorithm indicates the algorithm is neither HS256 nor RS256
+	ErrUnsupportedAlgorithm = errors.New("unsupported jwt algorithm")
+)
+
+type claimsKey struct{}
+
+type tokenKey struct{}
+
+// Claims jwt claims, registered claims exp, nbf, iat, iss and sub are reserved
+type Claims map[string]interface{}
+
+// Subject returns sub claim
+func (c Claims) Subject() string {
+	sub, _ := c["sub"].(string)
+	return sub
+}
+
+// Issuer returns iss claim
+func (c Claims) Issuer() string {
+	iss, _ := c["iss"].(string)
+	return iss
+}
+
+// ClaimsFromContext returns the claims of verified token
+func ClaimsFromContext(ctx context.Context) (Claims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(Claims)
+	return claims, ok
+}
+
+// WithToken returns a new context with the token, JWTForUnaryClient attaches it
+// instead of signing a new one, it's the way to pass user token to downstream services
+func WithToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, tokenKey{}, token)
+}
+
+// JWT signs and verifies json web token
+type JWT struct {
+	config     *config.JWTConfig
+	privateKey *rsa.PrivateKey
+	publicKey  *rsa.PublicKey
+}
+
+// NewJWT returns a JWT instance, RS256 keys are loaded if configured
+func NewJWT(cfg *config.JWTConfig) (*JWT, error) {
+	j := &JWT{config: cfg}
+	switch cfg.Algorithm {
+	case HS256:
+	case RS256:
+		var err error
+		if cfg.PrivateKeyPath != "" {
+			if j.privateKey, err = xcrypto.ParsePrivateKey(cfg.PrivateKeyPath); err != nil {
+				return nil, err
+			}
+		}
+		if cfg.PublicKeyPath != "" {
+			if j.publicKey, err = xcrypto.ParsePublicKey(cfg.PublicKeyPath); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, ErrUnsupportedAlgorithm
+	}
+	return j, nil
+}
+
+// Sign signs the claims and returns the token, iat, exp and iss are filled if absent
+func (j *JWT) Sign(claims Claims) (string, error) {
+	now := time.Now()
+	payload := make(Claims, len(claims)+3)
+	if j.config.Issuer != "" {
+		payload["iss"] = j.config.Issuer
+	}
+	payload["iat"] = now.Unix()
+	if j.config.Expire > 0 {
+		payload["exp"] = now.Add(j.config.Expire).Unix()
+	}
+	for key, val := range claims {
+		payload[key] = val
+	}
+
+	header, _ := json.Marshal(map[string]string{"alg": j.config.Algorithm, "typ": "JWT"})
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(body)
+	sign, err := j.sign(unsigned)
+	if err != nil {
+		return "", err
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(sign), nil
+}
+
+// Parse verifies the token and returns its claims
+func (j *JWT) Parse(token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+
+	header := make(map[string]string)
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, ErrInvalidToken
+	}
+	// never trust the algorithm claimed by token
+	if header["alg"] != j.config.Algorithm {
+		return nil, ErrInvalidToken
+	}
+
+	sign, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	if err = j.verify(parts[0]+"."+parts[1], sign); err != nil {
+		return nil, err
+	}
+
+	claims := make(Claims)
+	if err = decodeSegment(parts[1], &claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	now := time.Now().Unix()
+	if exp, ok := claims["exp"].(float64); ok && now >= int64(exp) {
+		return nil, ErrTokenExpired
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now < int64(nbf) {
+		return nil, ErrTokenExpired
+	}
+	if j.config.Issuer != "" && claims.Issuer() != j.config.Issuer {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// sign signs the content with the configured algorithm
+func (j *JWT) sign(content string) ([]byte, error) {
+	if j.config.Algorithm == RS256 {
+		if j.privateKey == nil {
+			return nil, xcrypto.ErrPrivateKey
+		}
+		hashed := sha256.Sum256([]byte(content))
+		return rsa.SignPKCS1v15(rand.Reader, j.privateKey, crypto.SHA256, hashed[:])
+	}
+	return xcrypto.HmacSHA256([]byte(j.config.Secret), content), nil
+}
+
+// verify verifies the signature of content with the configured algorithm
+func (j *JWT) verify(content string, sign []byte) error {
+	if j.config.Algorithm == RS256 {
+		if j.publicKey == nil {
+			return xcrypto.ErrPublicKey
+		}
+		hashed := sha256.Sum256([]byte(content))
+		if rsa.VerifyPKCS1v15(j.publicKey, crypto.SHA256, hashed[:], sign) != nil {
+			return ErrInvalidToken
+		}
+		return nil
+	}
+
+	if subtle.ConstantTimeCompare(xcrypto.HmacSHA256([]byte(j.config.Secret), content), sign) != 1 {
+		return ErrInvalidToken
+	}
+	return nil
+}
+
+// decodeSegment decodes base64 url encoded json segment of token
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// JWTForUnaryServer verifies the bearer token in metadata and places its claims on the context
+func JWTForUnaryServer(j *JWT) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		if skipAuth(j.config.Skip, info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		var token string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(authorizationKey); len(values) > 0 && strings.HasPrefix(values[0], bearerPrefix) {
+				token = strings.TrimPrefix(values[0], bearerPrefix)
+			}
+		}
+		if token == "" {
+			return nil, status.Unauthorized
+		}
+
+		claims, err := j.Parse(token)
+		if err != nil {
+			log.Warn(ctx, "jwt verify fail", log.String("method", info.FullMethod), log.String("error", err.Error()))
+			return nil, status.Unauthorized
+		}
+
+		return handler(context.WithValue(ctx, claimsKey{}, claims), req)
+	}
+}
+
+// JWTForUnaryClient attaches bearer token to metadata. The token passed by WithToken is preferred,
+// otherwise the client signs the claims itself and reuses the token until half of its lifetime passed.
+func JWTForUnaryClient(j *JWT, claims Claims) grpc.UnaryClientInterceptor {
+	var (
+		mutex   sync.Mutex
+		token   string
+		refresh time.Time
+	)
+
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) (err error) {
+		if skipAuth(j.config.Skip, method) {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		bearer, _ := ctx.Value(tokenKey{}).(string)
+		if bearer == "" {
+			mutex.Lock()
+			if token == "" || (j.config.Expire > 0 && time.Now().After(refresh)) {
+				if token, err = j.Sign(claims); err != nil {
+					mutex.Unlock()
+					return err
+				}
+				refresh = time.Now().Add(j.config.Expire / 2)
+			}
+			bearer = token
+			mutex.Unlock()
+		}
+
+		ctx = metadata.AppendToOutgoingContext(ctx, authorizationKey, bearerPrefix+bearer)
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// skipAuth reports whether the method escapes authentication, only full methods
+// are matched, so a skipped method name doesn't exempt it on other services
+func skipAuth(skip []string, fullMethod string) bool {
+	return xslice.ContainString(skip, fullMethod)
+}
@@ -0,0 +1,144 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package interceptors
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/UnderTreeTech/waterdrop/pkg/server/rpc/config"
+	"github.com/UnderTreeTech/waterdrop/pkg/status"
+
+	"github.com/stretchr/testify/assert"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestJWT(t *testing.T) {
+	t.Run("HS256", func(t *testing.T) {
+		j, err := NewJWT(&config.JWTConfig{Algorithm: HS256, Secret: "waterdrop", Issuer: "waterdrop", Expire: time.Minute})
+		assert.Nil(t, err)
+
+		token, err := j.Sign(Claims{"sub": "user"})
+		assert.Nil(t, err)
+		claims, err := j.Parse(token)
+		assert.Nil(t, err)
+		assert.Equal(t, "user", claims.Subject())
+		assert.Equal(t, "waterdrop", claims.Issuer())
+
+		_, err = j.Parse(token + "x")
+		assert.Equal(t, ErrInvalidToken, err)
+
+		other, _ := NewJWT(&config.JWTConfig{Algorithm: HS256, Secret: "other"})
+		_, err = other.Parse(token)
+		assert.Equal(t, ErrInvalidToken, err)
+
+		expired, _ := j.Sign(Claims{"exp": time.Now().Add(-time.Second).Unix()})
+		_, err = j.Parse(expired)
+		assert.Equal(t, ErrTokenExpired, err)
+	})
+
+	t.Run("RS256", func(t *testing.T) {
+		j, err := NewJWT(&config.JWTConfig{
+			Algorithm:      RS256,
+			PrivateKeyPath: "../../../utils/xcrypto/pem/rsa_private_key.pem",
+			PublicKeyPath:  "../../../utils/xcrypto/pem/rsa_public_key.pem",
+		})
+		assert.Nil(t, err)
+
+		token, err := j.Sign(Claims{"sub": "user"})
+		assert.Nil(t, err)
+		claims, err := j.Parse(token)
+		assert.Nil(t, err)
+		assert.Equal(t, "user", claims.Subject())
+
+		// the token signed by HS256 must be rejected
+		hs, _ := NewJWT(&config.JWTConfig{Algorithm: HS256, Secret: "waterdrop"})
+		token, _ = hs.Sign(Claims{"sub": "user"})
+		_, err = j.Parse(token)
+		assert.Equal(t, ErrInvalidToken, err)
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		_, err := NewJWT(&config.JWTConfig{Algorithm: "none"})
+		assert.Equal(t, ErrUnsupportedAlgorithm, err)
+	})
+}
+
+func TestJWTForUnaryServer(t *testing.T) {
+	j, _ := NewJWT(&config.JWTConfig{Algorithm: HS256, Secret: "waterdrop", Skip: []string{"/grpc.testing.TestService/EmptyCall"}})
+	interceptor := JWTForUnaryServer(j)
+	handler := func(ctx context.Context, req interface{}) (resp interface{}, err error) {
+		claims, _ := ClaimsFromContext(ctx)
+		return claims.Subject(), nil
+	}
+	info := &grpc.UnaryServerInfo{
+		FullMethod: "/grpc.testing.TestService/UnaryCall",
+	}
+
+	t.Run("success", func(t *testing.T) {
+		token, _ := j.Sign(Claims{"sub": "user"})
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token))
+		resp, err := interceptor(ctx, nil, info, handler)
+		assert.Nil(t, err)
+		assert.Equal(t, "user", resp)
+	})
+
+	t.Run("unauthorized", func(t *testing.T) {
+		_, err := interceptor(context.Background(), nil, info, handler)
+		assert.Equal(t, status.Unauthorized, err)
+
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer invalid"))
+		_, err = interceptor(ctx, nil, info, handler)
+		assert.Equal(t, status.Unauthorized, err)
+	})
+
+	t.Run("skip", func(t *testing.T) {
+		resp, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/grpc.testing.TestService/EmptyCall"}, handler)
+		assert.Nil(t, err)
+		assert.Equal(t, "", resp)
+	})
+}
+
+func TestJWTForUnaryClient(t *testing.T) {
+	j, _ := NewJWT(&config.JWTConfig{Algorithm: HS256, Secret: "waterdrop", Expire: time.Minute})
+	interceptor := JWTForUnaryClient(j, Claims{"sub": "client"})
+
+	var tokens []string
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) (err error) {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		tokens = append(tokens, md.Get("authorization")...)
+		return
+	}
+
+	assert.Nil(t, interceptor(context.Background(), "/grpc.testing.TestService/UnaryCall", nil, nil, nil, invoker))
+	assert.Nil(t, interceptor(context.Background(), "/grpc.testing.TestService/UnaryCall", nil, nil, nil, invoker))
+	assert.Equal(t, 2, len(tokens))
+	// the signed token is reused
+	assert.Equal(t, tokens[0], tokens[1])
+	claims, err := j.Parse(tokens[0][len("Bearer "):])
+	assert.Nil(t, err)
+	assert.Equal(t, "client", claims.Subject())
+
+	ctx := WithToken(context.Background(), "user-token")
+	assert.Nil(t, interceptor(ctx, "/grpc.testing.TestService/UnaryCall", nil, nil, nil, invoker))
+	assert.Equal(t, "Bearer user-token", tokens[2])
+}
@@ -31,7 +31,7 @@ import (
 	"google.golang.org/grpc"
 )
 
-// Metric metric handler, currently only metric at server side
+// Metric metric handler at server side
 func Metric() grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
 		now := time.Now()
@@ -49,3 +49,24 @@ func Metric() grpc.UnaryServerInterceptor {
 		return
 	}
 }
+
+// MetricForUnaryClient metric unary client requests by target and method
+func MetricForUnaryClient() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) (err error) {
+		now := time.Now()
+		var target string
+		if cc != nil {
+			target = cc.Target()
+		}
+
+		metric.UnaryClientInflight.Inc(target, method)
+		defer metric.UnaryClientInflight.Dec(target, method)
+
+		err = invoker(ctx, method, req, reply, cc, opts...)
+
+		estatus := status.ExtractStatus(err)
+		metric.UnaryClientHandleCounter.Inc(target, method, estatus.Error())
+		metric.UnaryClientReqDuration.Observe(time.Since(now).Seconds(), target, method)
+		return
+	}
+}
@@ -18,8 +18,34 @@
 
 package interceptors
 
-import "testing"
+import (
+	"context"
+	"testing"
+
+	"github.com/UnderTreeTech/waterdrop/pkg/stats/metric"
+	"github.com/UnderTreeTech/waterdrop/pkg/status"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/stretchr/testify/assert"
+
+	"google.golang.org/grpc"
+)
 
 func TestMetric(t *testing.T) {
 
 }
+
+func TestMetricForUnaryClient(t *testing.T) {
+	interceptor := MetricForUnaryClient()
+	method := "/grpc.testing.TestService/UnaryCall"
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) (err error) {
+		assert.Equal(t, float64(1), testutil.ToFloat64(metric.UnaryClientInflight.WithLabelValues("", method)))
+		return status.NothingFound
+	}
+
+	err := interceptor(context.Background(), method, nil, nil, nil, invoker)
+	assert.Equal(t, status.NothingFound, err)
+	assert.Equal(t, float64(0), testutil.ToFloat64(metric.UnaryClientInflight.WithLabelValues("", method)))
+	assert.Equal(t, float64(1), testutil.ToFloat64(metric.UnaryClientHandleCounter.WithLabelValues("", method, status.NothingFound.Error())))
+}
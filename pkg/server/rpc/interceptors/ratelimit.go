@@ -0,0 +1,134 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package interceptors
+
+import (
+	"context"
+	"math"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/UnderTreeTech/waterdrop/pkg/log"
+	baggage "github.com/UnderTreeTech/waterdrop/pkg/metadata"
+	"github.com/UnderTreeTech/waterdrop/pkg/ratelimit"
+	"github.com/UnderTreeTech/waterdrop/pkg/status"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// rate limit header keys
+const (
+	rateLimitLimit     = "x-ratelimit-limit"
+	rateLimitRemaining = "x-ratelimit-remaining"
+	rateLimitReset     = "x-ratelimit-reset"
+	retryAfter         = "retry-after"
+)
+
+// LimitKeyFunc returns the rate limit key of request
+type LimitKeyFunc func(ctx context.Context, info *grpc.UnaryServerInfo) string
+
+// LimitByMethod limits requests by full method
+func LimitByMethod(_ context.Context, info *grpc.UnaryServerInfo) string {
+	return info.FullMethod
+}
+
+// LimitByIP limits requests by peer ip
+func LimitByIP(ctx context.Context, _ *grpc.UnaryServerInfo) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	addr := p.Addr.String()
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// LimitByAppKey limits requests by appkey of metadata or baggage
+func LimitByAppKey(ctx context.Context, _ *grpc.UnaryServerInfo) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if appkey := firstValue(md, appkeyKey); appkey != "" {
+			return appkey
+		}
+	}
+	return baggage.GetAppKey(ctx)
+}
+
+// RateLimitForUnaryServer limits requests by limiter, requests are limited by method if key is nil.
+// If the limiter is a Taker, such as limiters in pkg/ratelimit, x-ratelimit-* headers are sent.
+// Requests are allowed if Taker fails to take token, so that a broken limiter backend such as
+// redis doesn't block all the requests.
+func RateLimitForUnaryServer(limiter ratelimit.Limiter, key LimitKeyFunc) grpc.UnaryServerInterceptor {
+	if key == nil {
+		key = LimitByMethod
+	}
+
+	taker, _ := limiter.(ratelimit.Taker)
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		limitKey := key(ctx, info)
+
+		allowed := true
+		if taker != nil {
+			result, terr := taker.Take(ctx, limitKey)
+			if terr != nil {
+				log.Warn(ctx, "take rate limit token fail", log.String("key", limitKey), log.String("error", terr.Error()))
+			} else {
+				grpc.SetHeader(ctx, limitHeaders(result))
+				allowed = result.Allowed
+			}
+		} else {
+			allowed = limiter.Allow(ctx, limitKey)
+		}
+
+		if !allowed {
+			log.Warn(ctx,
+				"rpc hit rate limit",
+				log.String("kind", "server"),
+				log.String("method", info.FullMethod),
+				log.String("key", limitKey),
+			)
+			return nil, status.LimitExceed
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// limitHeaders returns quota headers, durations are rounded up to seconds
+func limitHeaders(result *ratelimit.Result) metadata.MD {
+	md := metadata.Pairs(
+		rateLimitLimit, strconv.FormatInt(result.Limit, 10),
+		rateLimitRemaining, strconv.FormatInt(result.Remaining, 10),
+		rateLimitReset, ceilSeconds(result.Reset),
+	)
+	if !result.Allowed {
+		md.Set(retryAfter, ceilSeconds(result.RetryAfter))
+	}
+	return md
+}
+
+// ceilSeconds formats duration in seconds rounded up
+func ceilSeconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
@@ -0,0 +1,93 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package interceptors
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/UnderTreeTech/waterdrop/pkg/ratelimit"
+	"github.com/UnderTreeTech/waterdrop/pkg/status"
+
+	"github.com/stretchr/testify/assert"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+func TestRateLimitUnaryServer(t *testing.T) {
+	interceptor := RateLimitForUnaryServer(ratelimit.NewSlidingWindow(1, time.Minute), LimitByAppKey)
+	info := &grpc.UnaryServerInfo{FullMethod: "/grpc.testing.TestService/UnaryCall"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	}
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("appkey", "app"))
+	resp, err := interceptor(ctx, nil, info, handler)
+	assert.Nil(t, err)
+	assert.Equal(t, "ok", resp)
+
+	_, err = interceptor(ctx, nil, info, handler)
+	assert.Equal(t, status.LimitExceed, err)
+
+	ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs("appkey", "another"))
+	_, err = interceptor(ctx, nil, info, handler)
+	assert.Nil(t, err)
+}
+
+// errTaker fails to take tokens
+type errTaker struct{}
+
+func (errTaker) Allow(context.Context, string) bool { return false }
+func (errTaker) Take(context.Context, string) (*ratelimit.Result, error) {
+	return nil, errors.New("redis down")
+}
+
+func TestRateLimitTakeFail(t *testing.T) {
+	interceptor := RateLimitForUnaryServer(errTaker{}, nil)
+	info := &grpc.UnaryServerInfo{FullMethod: "/grpc.testing.TestService/UnaryCall"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	}
+
+	// requests are allowed if taking token fails
+	resp, err := interceptor(context.Background(), nil, info, handler)
+	assert.Nil(t, err)
+	assert.Equal(t, "ok", resp)
+}
+
+func TestLimitKey(t *testing.T) {
+	info := &grpc.UnaryServerInfo{FullMethod: "/grpc.testing.TestService/UnaryCall"}
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 8080}})
+	assert.Equal(t, "10.0.0.1", LimitByIP(ctx, info))
+	assert.Equal(t, info.FullMethod, LimitByMethod(ctx, info))
+	assert.Equal(t, "", LimitByAppKey(ctx, info))
+}
+
+func TestLimitHeaders(t *testing.T) {
+	md := limitHeaders(&ratelimit.Result{Limit: 10, Remaining: 0, RetryAfter: 1500 * time.Millisecond, Reset: 3 * time.Second})
+	assert.Equal(t, []string{"10"}, md.Get("x-ratelimit-limit"))
+	assert.Equal(t, []string{"0"}, md.Get("x-ratelimit-remaining"))
+	assert.Equal(t, []string{"3"}, md.Get("x-ratelimit-reset"))
+	assert.Equal(t, []string{"2"}, md.Get("retry-after"))
+}
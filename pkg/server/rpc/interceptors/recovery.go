@@ -21,6 +21,7 @@ package interceptors
 import (
 	"context"
 	"runtime"
+	"strings"
 	"time"
 
 	"github.com/UnderTreeTech/waterdrop/pkg/server/rpc/config"
@@ -87,7 +88,7 @@ func RecoveryForUnaryClient(config *config.ClientConfig) grpc.UnaryClientInterce
 		}()
 
 		// adjust request timeout
-		timeout := config.Timeout
+		timeout := methodTimeout(config, method)
 		if deadline, ok := ctx.Deadline(); ok {
 			derivedTimeout := time.Until(deadline)
 			if timeout > derivedTimeout {
@@ -108,3 +109,14 @@ func RecoveryForUnaryClient(config *config.ClientConfig) grpc.UnaryClientInterce
 		return
 	}
 }
+
+// methodTimeout returns the timeout of method, full method is preferred to method name
+func methodTimeout(config *config.ClientConfig, method string) time.Duration {
+	if timeout, ok := config.Timeouts[method]; ok {
+		return timeout
+	}
+	if timeout, ok := config.Timeouts[method[strings.LastIndex(method, "/")+1:]]; ok {
+		return timeout
+	}
+	return config.Timeout
+}
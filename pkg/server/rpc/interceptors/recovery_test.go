@@ -22,6 +22,7 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/UnderTreeTech/waterdrop/pkg/server/rpc/config"
 
@@ -65,3 +66,23 @@ func TestRecoveryForUnaryClient(t *testing.T) {
 		assert.Equal(t, status.ServerErr.Code(), errStatus.Code())
 	})
 }
+
+func TestMethodTimeout(t *testing.T) {
+	cfg := config.DefaultClientConfig()
+	cfg.Timeouts = map[string]time.Duration{
+		"UnaryCall":                           time.Second,
+		"/grpc.testing.TestService/EmptyCall": 2 * time.Second,
+	}
+	assert.Equal(t, time.Second, methodTimeout(cfg, "/grpc.testing.TestService/UnaryCall"))
+	assert.Equal(t, 2*time.Second, methodTimeout(cfg, "/grpc.testing.TestService/EmptyCall"))
+	assert.Equal(t, cfg.Timeout, methodTimeout(cfg, "/grpc.testing.TestService/StreamingCall"))
+
+	interceptor := RecoveryForUnaryClient(cfg)
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) (err error) {
+		deadline, ok := ctx.Deadline()
+		assert.True(t, ok)
+		assert.True(t, time.Until(deadline) > cfg.Timeout)
+		return
+	}
+	assert.Nil(t, interceptor(context.Background(), "/grpc.testing.TestService/UnaryCall", nil, nil, nil, invoker))
+}
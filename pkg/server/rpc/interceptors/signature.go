@@ -0,0 +1,170 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package interceptors
+
+import (
+	"context"
+	"crypto/subtle"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/UnderTreeTech/waterdrop/pkg/log"
+	"github.com/UnderTreeTech/waterdrop/pkg/server/nonce"
+	"github.com/UnderTreeTech/waterdrop/pkg/server/rpc/config"
+	"github.com/UnderTreeTech/waterdrop/pkg/status"
+	"github.com/UnderTreeTech/waterdrop/pkg/utils/xcrypto"
+	"github.com/UnderTreeTech/waterdrop/pkg/utils/xstring"
+	"github.com/UnderTreeTech/waterdrop/pkg/utils/xtime"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	appkeyKey    = "appkey"
+	signKey      = "sign"
+	nonceKey     = "nonce"
+	timestampKey = "timestamp"
+
+	nonceLen = 16
+	// defaultNonceTTL nonce ttl if timestamp is not checked
+	defaultNonceTTL = 10 * time.Minute
+)
+
+// SecretFunc returns the signature secret of appkey
+type SecretFunc func(ctx context.Context, appkey string) (secret string, err error)
+
+// sign sign algorithm:md5(method + body + secret + timestamp + nonce), body is the
+// deterministic protobuf encoding of req, so map fields are encoded in the same order.
+// It's the same as http client MD5Signature except that query params are replaced by method
+func sign(method string, req interface{}, secret string, ts string, nonce string) (string, error) {
+	message, ok := req.(proto.Message)
+	if !ok {
+		return "", status.SignCheckErr
+	}
+	body, err := proto.MarshalOptions{Deterministic: true}.Marshal(message)
+	if err != nil {
+		return "", err
+	}
+
+	sb := strings.Builder{}
+	sb.WriteString(method)
+	sb.Write(body)
+	sb.WriteString(secret)
+	sb.WriteString(ts)
+	sb.WriteString(nonce)
+	return xcrypto.HashToString(sb.String(), xcrypto.MD5, xcrypto.HEX)
+}
+
+// SignatureForUnaryServer verifies appkey signature in metadata.
+// A request is rejected if its appkey is invalid, its timestamp is stale, its sign mismatches
+// or its nonce has been seen. Replays are not checked if nonces is nil.
+func SignatureForUnaryServer(cfg *config.SignatureConfig, secretFunc SecretFunc, nonces nonce.Store) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		if skipAuth(cfg.Skip, info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		md, _ := metadata.FromIncomingContext(ctx)
+		appkey := firstValue(md, appkeyKey)
+		if appkey == "" {
+			return nil, status.AppKeyInvalid
+		}
+
+		secret, err := secretFunc(ctx, appkey)
+		if err != nil || secret == "" {
+			log.Warn(ctx, "appkey secret not found", log.String("appkey", appkey), log.Any("error", err))
+			return nil, status.AppKeyInvalid
+		}
+
+		ts := firstValue(md, timestampKey)
+		unix, err := strconv.ParseInt(ts, 10, 64)
+		if err != nil {
+			return nil, status.SignCheckErr
+		}
+		if cfg.Expire > 0 {
+			if gap := time.Since(time.Unix(unix, 0)); gap > cfg.Expire || gap < -cfg.Expire {
+				log.Warn(ctx, "stale signature timestamp", log.String("appkey", appkey), log.String("timestamp", ts))
+				return nil, status.SignCheckErr
+			}
+		}
+
+		nonceValue := firstValue(md, nonceKey)
+		expected, err := sign(info.FullMethod, req, secret, ts, nonceValue)
+		if err != nil {
+			return nil, status.SignCheckErr
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(firstValue(md, signKey))) != 1 {
+			log.Warn(ctx, "signature mismatch", log.String("appkey", appkey), log.String("method", info.FullMethod))
+			return nil, status.SignCheckErr
+		}
+
+		// the nonce is kept until the timestamp expires, so it can't be replayed in the window
+		if nonces != nil {
+			ttl := 2 * cfg.Expire
+			if ttl <= 0 {
+				ttl = defaultNonceTTL
+			}
+			added, err := nonces.Add(ctx, appkey+":"+nonceValue, ttl)
+			if err != nil {
+				log.Error(ctx, "record nonce fail", log.String("appkey", appkey), log.String("error", err.Error()))
+				return nil, status.ServerErr
+			}
+			if !added {
+				return nil, status.RepeatedRequest
+			}
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// SignatureForUnaryClient signs request with appkey and secret, attaches the signature to metadata
+func SignatureForUnaryClient(cfg *config.SignatureConfig) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) (err error) {
+		if skipAuth(cfg.Skip, method) {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		ts := strconv.FormatInt(xtime.Now().CurrentUnixTime(), 10)
+		nonce := xstring.RandomString(nonceLen)
+		signature, err := sign(method, req, cfg.Secret, ts, nonce)
+		if err != nil {
+			return err
+		}
+
+		ctx = metadata.AppendToOutgoingContext(ctx,
+			appkeyKey, cfg.Key,
+			signKey, signature,
+			nonceKey, nonce,
+			timestampKey, ts,
+		)
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// firstValue returns the first value of key in metadata
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
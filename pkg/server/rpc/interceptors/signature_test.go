@@ -0,0 +1,107 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package interceptors
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/UnderTreeTech/waterdrop/pkg/server/nonce"
+	"github.com/UnderTreeTech/waterdrop/pkg/server/rpc/config"
+	"github.com/UnderTreeTech/waterdrop/pkg/status"
+
+	"github.com/stretchr/testify/assert"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/grpc_testing"
+)
+
+func TestSignature(t *testing.T) {
+	secrets := func(ctx context.Context, appkey string) (string, error) {
+		if appkey == "waterdrop" {
+			return "secret", nil
+		}
+		return "", errors.New("appkey not found")
+	}
+	cfg := &config.SignatureConfig{Expire: time.Minute, Skip: []string{"/grpc.testing.TestService/EmptyCall"}}
+	server := SignatureForUnaryServer(cfg, secrets, nonce.NewMemoryStore(1024))
+	handler := func(ctx context.Context, req interface{}) (resp interface{}, err error) {
+		return req, nil
+	}
+	method := "/grpc.testing.TestService/UnaryCall"
+	req := &grpc_testing.SimpleRequest{ResponseSize: 1, Payload: &grpc_testing.Payload{Body: []byte("waterdrop")}}
+
+	// call client interceptor and pass its metadata to server interceptor
+	call := func(cfg *config.SignatureConfig, method string, req interface{}) error {
+		invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) (err error) {
+			md, _ := metadata.FromOutgoingContext(ctx)
+			_, err = server(metadata.NewIncomingContext(ctx, md), req, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+			return
+		}
+		return SignatureForUnaryClient(cfg)(context.Background(), method, req, nil, nil, invoker)
+	}
+
+	t.Run("success", func(t *testing.T) {
+		assert.Nil(t, call(&config.SignatureConfig{Key: "waterdrop", Secret: "secret"}, method, req))
+	})
+
+	t.Run("invalid appkey", func(t *testing.T) {
+		assert.Equal(t, status.AppKeyInvalid, call(&config.SignatureConfig{Key: "unknown", Secret: "secret"}, method, req))
+	})
+
+	t.Run("sign mismatch", func(t *testing.T) {
+		assert.Equal(t, status.SignCheckErr, call(&config.SignatureConfig{Key: "waterdrop", Secret: "wrong"}, method, req))
+	})
+
+	t.Run("stale timestamp", func(t *testing.T) {
+		ts := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+		signature, _ := sign(method, req, "secret", ts, "nonce")
+		md := metadata.Pairs("appkey", "waterdrop", "sign", signature, "nonce", "nonce", "timestamp", ts)
+		_, err := server(metadata.NewIncomingContext(context.Background(), md), req, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+		assert.Equal(t, status.SignCheckErr, err)
+	})
+
+	t.Run("replay", func(t *testing.T) {
+		ts := strconv.FormatInt(time.Now().Unix(), 10)
+		signature, _ := sign(method, req, "secret", ts, "replay")
+		md := metadata.Pairs("appkey", "waterdrop", "sign", signature, "nonce", "replay", "timestamp", ts)
+		ctx := metadata.NewIncomingContext(context.Background(), md)
+		_, err := server(ctx, req, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+		assert.Nil(t, err)
+		_, err = server(ctx, req, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+		assert.Equal(t, status.RepeatedRequest, err)
+	})
+
+	t.Run("non proto request", func(t *testing.T) {
+		assert.Equal(t, status.SignCheckErr, call(&config.SignatureConfig{Key: "waterdrop", Secret: "secret"}, method, &Mock{}))
+	})
+
+	t.Run("skip", func(t *testing.T) {
+		_, err := server(context.Background(), req, &grpc.UnaryServerInfo{FullMethod: "/grpc.testing.TestService/EmptyCall"}, handler)
+		assert.Nil(t, err)
+
+		// method name of other services isn't skipped
+		_, err = server(context.Background(), req, &grpc.UnaryServerInfo{FullMethod: "/grpc.testing.OtherService/EmptyCall"}, handler)
+		assert.Equal(t, status.AppKeyInvalid, err)
+	})
+}
@@ -27,6 +27,8 @@ import (
 
 	"github.com/opentracing/opentracing-go/ext"
 
+	baggage "github.com/UnderTreeTech/waterdrop/pkg/metadata"
+	"github.com/UnderTreeTech/waterdrop/pkg/registry"
 	"github.com/UnderTreeTech/waterdrop/pkg/status"
 	"github.com/UnderTreeTech/waterdrop/pkg/trace"
 	"github.com/opentracing/opentracing-go/log"
@@ -39,6 +41,13 @@ func TraceForUnaryServer() grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
 		opt := trace.FromIncomingContext(ctx)
 		span, ctx := trace.StartSpanFromContext(ctx, info.FullMethod, opt)
+		// pass routing color and baggage to downstream services
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if colors := md.Get(registry.ColorHeader); len(colors) > 0 && colors[0] != "" {
+				ctx = registry.WithColor(ctx, colors[0])
+			}
+			ctx = baggage.ExtractMD(ctx, md)
+		}
 		ext.Component.Set(span, "grpc")
 		ext.SpanKind.Set(span, ext.SpanKindRPCServerEnum)
 		if peer, ok := peer.FromContext(ctx); ok {
@@ -69,6 +78,11 @@ func TraceForUnaryClient() grpc.UnaryClientInterceptor {
 		ext.SpanKind.Set(span, ext.SpanKindRPCClientEnum)
 		defer span.Finish()
 
+		if color := registry.ColorFromContext(ctx); color != "" {
+			md.Set(registry.ColorHeader, color)
+		}
+		baggage.InjectMD(ctx, md)
+		ctx = metadata.NewOutgoingContext(ctx, md)
 		ctx = trace.MetadataInjector(ctx, md)
 		err = invoker(ctx, method, req, reply, cc, opts...)
 		if err != nil {
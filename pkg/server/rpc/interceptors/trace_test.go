@@ -23,6 +23,8 @@ import (
 	"fmt"
 	"testing"
 
+	baggage "github.com/UnderTreeTech/waterdrop/pkg/metadata"
+	"github.com/UnderTreeTech/waterdrop/pkg/registry"
 	"github.com/UnderTreeTech/waterdrop/pkg/trace"
 
 	"github.com/opentracing/opentracing-go"
@@ -33,6 +35,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 )
 
 func newJaegerClient() (opentracing.Tracer, func()) {
@@ -74,6 +77,60 @@ func TestTraceForUnaryServer(t *testing.T) {
 	})
 }
 
+func TestTraceColor(t *testing.T) {
+	info := &grpc.UnaryServerInfo{
+		FullMethod: "/grpc.testing.TestService/UnaryCall",
+	}
+
+	t.Run("server", func(t *testing.T) {
+		handler := func(ctx context.Context, req interface{}) (resp interface{}, err error) {
+			return registry.ColorFromContext(ctx), nil
+		}
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(registry.ColorHeader, "canary"))
+		resp, err := TraceForUnaryServer()(ctx, nil, info, handler)
+		assert.Nil(t, err)
+		assert.Equal(t, "canary", resp)
+	})
+
+	t.Run("client", func(t *testing.T) {
+		invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) (err error) {
+			md, _ := metadata.FromOutgoingContext(ctx)
+			assert.Equal(t, []string{"canary"}, md.Get(registry.ColorHeader))
+			return
+		}
+		ctx := registry.WithColor(context.Background(), "canary")
+		err := TraceForUnaryClient()(ctx, "/grpc.testing.TestService/UnaryCall", nil, nil, nil, invoker)
+		assert.Nil(t, err)
+	})
+}
+
+func TestTraceBaggage(t *testing.T) {
+	info := &grpc.UnaryServerInfo{
+		FullMethod: "/grpc.testing.TestService/UnaryCall",
+	}
+
+	t.Run("server", func(t *testing.T) {
+		handler := func(ctx context.Context, req interface{}) (resp interface{}, err error) {
+			return baggage.GetUserID(ctx), nil
+		}
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(baggage.Prefix+baggage.UserID, "10086"))
+		resp, err := TraceForUnaryServer()(ctx, nil, info, handler)
+		assert.Nil(t, err)
+		assert.Equal(t, "10086", resp)
+	})
+
+	t.Run("client", func(t *testing.T) {
+		invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) (err error) {
+			md, _ := metadata.FromOutgoingContext(ctx)
+			assert.Equal(t, []string{"10086"}, md.Get(baggage.Prefix+baggage.UserID))
+			return
+		}
+		ctx := baggage.WithUserID(context.Background(), "10086")
+		err := TraceForUnaryClient()(ctx, "/grpc.testing.TestService/UnaryCall", nil, nil, nil, invoker)
+		assert.Nil(t, err)
+	})
+}
+
 func TestTraceForUnaryClient(t *testing.T) {
 	interceptor := TraceForUnaryClient()
 	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) (err error) {
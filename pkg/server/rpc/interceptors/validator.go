@@ -21,25 +21,51 @@ package interceptors
 import (
 	"context"
 
-	"github.com/go-playground/validator/v10"
+	"github.com/UnderTreeTech/waterdrop/pkg/log"
+	baggage "github.com/UnderTreeTech/waterdrop/pkg/metadata"
+	"github.com/UnderTreeTech/waterdrop/pkg/status"
+	"github.com/UnderTreeTech/waterdrop/pkg/validator"
 
+	validatorv10 "github.com/go-playground/validator/v10"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 )
 
-var v = validator.New()
+const acceptLanguageKey = "accept-language"
 
-// ValidateForUnaryServer validate input request params
+// ValidateForUnaryServer validate input request params. Messages generated by protoc-gen-validate are
+// validated by their own Validate methods, the others are validated by struct tags.
+// Violations are reported by status.RequestErr with google.rpc.BadRequest detail,
+// descriptions of struct tag violations are localized by accept-language metadata.
 func ValidateForUnaryServer() grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
-		if err = v.Struct(req); err != nil {
-			return
+		if violations := validator.Validate(req, locale(ctx)); len(violations) > 0 {
+			estatus, derr := status.RequestErr.WithDetails(&errdetails.BadRequest{FieldViolations: violations})
+			if derr != nil {
+				log.Error(ctx, "attach bad request detail fail", log.String("error", derr.Error()))
+				return nil, status.RequestErr
+			}
+			return nil, estatus
 		}
 		return handler(ctx, req)
 	}
 }
 
+// locale returns the request locale language, accept-language metadata is preferred to baggage
+func locale(ctx context.Context) string {
+	lng := baggage.GetLocale(ctx)
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(acceptLanguageKey); len(values) > 0 {
+			lng = values[0]
+		}
+	}
+	return lng
+}
+
 // GetValidator returns the underlying validator engine which powers the
 // StructValidator implementation.
-func GetValidator() *validator.Validate {
-	return v
+func GetValidator() *validatorv10.Validate {
+	return validator.GetValidator()
 }
@@ -20,12 +20,19 @@ package interceptors
 
 import (
 	"context"
+	"errors"
 	"testing"
 
+	"github.com/UnderTreeTech/waterdrop/pkg/status"
+
 	"github.com/go-playground/validator/v10"
 
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	gstatus "google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
 type Mock struct {
@@ -70,3 +77,95 @@ func TestGetValidator(t *testing.T) {
 	v := GetValidator()
 	assert.IsType(t, &validator.Validate{}, v)
 }
+
+// pgvMock mocks protoc-gen-validate generated message
+type pgvMock struct {
+	err error
+}
+
+func (m *pgvMock) Validate() error {
+	return m.err
+}
+
+// pgvError mocks protoc-gen-validate generated validation error
+type pgvError struct {
+	field  string
+	reason string
+	cause  error
+}
+
+func (e pgvError) Field() string  { return e.field }
+func (e pgvError) Reason() string { return e.reason }
+func (e pgvError) Cause() error   { return e.cause }
+func (e pgvError) Error() string  { return e.field + ": " + e.reason }
+
+// pgvMultiError mocks protoc-gen-validate generated multi error
+type pgvMultiError []error
+
+func (m pgvMultiError) Error() string      { return "multi error" }
+func (m pgvMultiError) AllErrors() []error { return m }
+
+// violations extracts field violations of bad request detail
+func violations(t *testing.T, err error) map[string]string {
+	estatus := status.ExtractStatus(err)
+	assert.Equal(t, status.RequestErr.Code(), estatus.Code())
+
+	fields := make(map[string]string)
+	for _, detail := range estatus.Details() {
+		if br, ok := detail.(protoreflect.Message).Interface().(*errdetails.BadRequest); ok {
+			for _, v := range br.GetFieldViolations() {
+				fields[v.GetField()] = v.GetDescription()
+			}
+		}
+	}
+	return fields
+}
+
+func TestValidateDetails(t *testing.T) {
+	interceptor := ValidateForUnaryServer()
+	handler := func(ctx context.Context, req interface{}) (resp interface{}, err error) {
+		return nil, nil
+	}
+	info := &grpc.UnaryServerInfo{
+		FullMethod: "/grpc.testing.TestService/UnaryCall",
+	}
+	failMock := &Mock{Name: "waterdrop", Email: "waterdrop", Age: 30}
+
+	t.Run("struct tag", func(t *testing.T) {
+		_, err := interceptor(context.Background(), failMock, info, handler)
+		assert.Equal(t, map[string]string{"Email": "Email must be a valid email address"}, violations(t, err))
+	})
+
+	t.Run("localized", func(t *testing.T) {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("accept-language", "zh-CN"))
+		_, err := interceptor(ctx, failMock, info, handler)
+		assert.Equal(t, map[string]string{"Email": "Email必须是一个有效的邮箱"}, violations(t, err))
+	})
+
+	t.Run("protoc-gen-validate", func(t *testing.T) {
+		req := &pgvMock{err: pgvMultiError{
+			pgvError{field: "name", reason: "value length must be at least 6 runes"},
+			pgvError{field: "payload", reason: "embedded message failed validation", cause: pgvError{field: "body", reason: "value is required"}},
+		}}
+		_, err := interceptor(context.Background(), req, info, handler)
+		assert.Equal(t, map[string]string{
+			"name":         "value length must be at least 6 runes",
+			"payload.body": "value is required",
+		}, violations(t, err))
+
+		_, err = interceptor(context.Background(), &pgvMock{}, info, handler)
+		assert.Nil(t, err)
+	})
+
+	t.Run("over the wire", func(t *testing.T) {
+		_, err := interceptor(context.Background(), failMock, info, handler)
+		// details survive the conversion of grpc status
+		wired := gstatus.FromProto(gstatus.Convert(err).Proto()).Err()
+		assert.Equal(t, map[string]string{"Email": "Email must be a valid email address"}, violations(t, wired))
+	})
+
+	t.Run("unknown error", func(t *testing.T) {
+		_, err := interceptor(context.Background(), &pgvMock{err: errors.New("invalid")}, info, handler)
+		assert.Equal(t, map[string]string{"": "invalid"}, violations(t, err))
+	})
+}
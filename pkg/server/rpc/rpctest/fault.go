@@ -0,0 +1,113 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package rpctest
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/UnderTreeTech/waterdrop/pkg/status"
+
+	"google.golang.org/grpc"
+)
+
+// Fault fault injected into method
+type Fault struct {
+	// Delay latency before handling request, it's interrupted once request context done
+	Delay time.Duration
+	// Err error returned instead of handling request
+	Err error
+	// Panic value to panic with instead of handling request
+	Panic interface{}
+	// Times number of requests the fault affects, zero means forever
+	Times int64
+
+	hits int64
+}
+
+// take reports whether the fault affects current request
+func (f *Fault) take() bool {
+	return f.Times <= 0 || atomic.AddInt64(&f.hits, 1) <= f.Times
+}
+
+// faults faults keyed by method name or full method
+type faults struct {
+	m sync.Map
+}
+
+func (fs *faults) inject(method string, fault *Fault) {
+	fs.m.Store(method, fault)
+}
+
+func (fs *faults) reset(methods ...string) {
+	if len(methods) == 0 {
+		fs.m.Range(func(key, value interface{}) bool {
+			fs.m.Delete(key)
+			return true
+		})
+		return
+	}
+
+	for _, method := range methods {
+		fs.m.Delete(method)
+	}
+}
+
+// get returns fault of method, full method is preferred to method name
+func (fs *faults) get(fullMethod string) (*Fault, bool) {
+	if fault, ok := fs.m.Load(fullMethod); ok {
+		return fault.(*Fault), true
+	}
+	if fault, ok := fs.m.Load(fullMethod[strings.LastIndex(fullMethod, "/")+1:]); ok {
+		return fault.(*Fault), true
+	}
+	return nil, false
+}
+
+// interceptor applies the faults of request method
+func (fs *faults) interceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		fault, ok := fs.get(info.FullMethod)
+		if !ok || !fault.take() {
+			return handler(ctx, req)
+		}
+
+		if fault.Delay > 0 {
+			timer := time.NewTimer(fault.Delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return nil, status.ExtractContextStatus(ctx.Err())
+			case <-timer.C:
+			}
+		}
+
+		if fault.Panic != nil {
+			panic(fault.Panic)
+		}
+
+		if fault.Err != nil {
+			return nil, fault.Err
+		}
+		return handler(ctx, req)
+	}
+}
@@ -0,0 +1,114 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// Package rpctest provides in-memory rpc server and client for integration test
+package rpctest
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/UnderTreeTech/waterdrop/pkg/server/rpc/client"
+	"github.com/UnderTreeTech/waterdrop/pkg/server/rpc/config"
+	"github.com/UnderTreeTech/waterdrop/pkg/server/rpc/server"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const (
+	// bufSize buffer size of in-memory connection
+	bufSize = 1024 * 1024
+	// Target dial target of in-memory server
+	Target = "bufnet"
+)
+
+// Server rpc server listens on in-memory connection with the default interceptors
+type Server struct {
+	server   *server.Server
+	listener *bufconn.Listener
+	faults   *faults
+	once     sync.Once
+}
+
+// NewServer returns a Server, faults injected by Inject take effect after all the interceptors
+// added before Start, so that recovery, trace, logger and metric interceptors see them
+func NewServer(cfg *config.ServerConfig) *Server {
+	return &Server{
+		server:   server.New(cfg),
+		listener: bufconn.Listen(bufSize),
+		faults:   &faults{},
+	}
+}
+
+// Server returns underlying grpc Server, register services on it before Start
+func (s *Server) Server() *grpc.Server {
+	return s.server.Server()
+}
+
+// Use attaches server interceptors
+func (s *Server) Use(interceptors ...grpc.UnaryServerInterceptor) {
+	s.server.Use(interceptors...)
+}
+
+// Inject injects fault into the method, method may be method name or full method
+func (s *Server) Inject(method string, fault *Fault) {
+	s.faults.inject(method, fault)
+}
+
+// Reset removes faults of the methods, all the faults are removed if no method specified
+func (s *Server) Reset(methods ...string) {
+	s.faults.reset(methods...)
+}
+
+// Start serves on in-memory listener and returns the listener address, it's safe to call Start
+// more than once
+func (s *Server) Start() net.Addr {
+	s.once.Do(func() {
+		s.server.Use(s.faults.interceptor())
+		go s.server.Server().Serve(s.listener)
+	})
+	return s.listener.Addr()
+}
+
+// Stop stops the server gracefully
+func (s *Server) Stop(ctx context.Context) error {
+	return s.server.Stop(ctx)
+}
+
+// Dialer returns a dialer connects to the in-memory server
+func (s *Server) Dialer() func(context.Context, string) (net.Conn, error) {
+	return func(context.Context, string) (net.Conn, error) {
+		return s.listener.Dial()
+	}
+}
+
+// NewClient returns a client with the default interceptors dials the in-memory server.
+// The target of config is always replaced by Target and a default config is used if it's nil.
+func (s *Server) NewClient(cfg *config.ClientConfig) *client.Client {
+	if cfg == nil {
+		cfg = config.DefaultClientConfig()
+		cfg.DialTimeout = time.Second
+	}
+
+	copied := *cfg
+	copied.Target = Target
+	return client.New(&copied, grpc.WithContextDialer(s.Dialer()))
+}
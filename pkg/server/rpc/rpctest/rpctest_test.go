@@ -0,0 +1,108 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package rpctest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/UnderTreeTech/waterdrop/pkg/log"
+	"github.com/UnderTreeTech/waterdrop/pkg/server/rpc/config"
+	"github.com/UnderTreeTech/waterdrop/pkg/status"
+	"github.com/UnderTreeTech/waterdrop/tests/proto/demo"
+
+	"github.com/stretchr/testify/assert"
+
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func TestRpcTest(t *testing.T) {
+	defer log.New(nil).Sync()
+
+	srv := NewServer(nil)
+	demo.RegisterDemoServer(srv.Server(), &service{})
+	srv.Start()
+	defer srv.Stop(context.Background())
+
+	cfg := config.DefaultClientConfig()
+	cfg.Timeout = 100 * time.Millisecond
+	cli := srv.NewClient(cfg)
+	defer cli.GetConn().Close()
+	rpc := demo.NewDemoClient(cli.GetConn())
+
+	t.Run("success", func(t *testing.T) {
+		reply, err := rpc.SayHelloURL(context.Background(), &demo.HelloReq{Name: "waterdrop"})
+		assert.Nil(t, err)
+		assert.Equal(t, "Hello waterdrop", reply.Content)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		defer srv.Reset()
+		srv.Inject("SayHelloURL", &Fault{Err: status.NothingFound, Times: 1})
+		_, err := rpc.SayHelloURL(context.Background(), &demo.HelloReq{Name: "waterdrop"})
+		assert.Equal(t, status.NothingFound.Code(), status.ExtractStatus(err).Code())
+
+		// the fault affects only once
+		_, err = rpc.SayHelloURL(context.Background(), &demo.HelloReq{Name: "waterdrop"})
+		assert.Nil(t, err)
+	})
+
+	t.Run("start twice", func(t *testing.T) {
+		defer srv.Reset()
+		srv.Start()
+		srv.Inject("SayHelloURL", &Fault{Err: status.NothingFound, Times: 2})
+		for i := 0; i < 2; i++ {
+			_, err := rpc.SayHelloURL(context.Background(), &demo.HelloReq{Name: "waterdrop"})
+			assert.Equal(t, status.NothingFound.Code(), status.ExtractStatus(err).Code())
+		}
+		_, err := rpc.SayHelloURL(context.Background(), &demo.HelloReq{Name: "waterdrop"})
+		assert.Nil(t, err)
+	})
+
+	t.Run("delay", func(t *testing.T) {
+		defer srv.Reset("/service.demo.v1.Demo/SayHelloURL")
+		srv.Inject("/service.demo.v1.Demo/SayHelloURL", &Fault{Delay: time.Second})
+		now := time.Now()
+		_, err := rpc.SayHelloURL(context.Background(), &demo.HelloReq{Name: "waterdrop"})
+		assert.Equal(t, status.Deadline.Code(), status.ExtractStatus(err).Code())
+		assert.True(t, time.Since(now) < time.Second)
+
+		// other methods are not affected
+		_, err = rpc.SayHello(context.Background(), &demo.HelloReq{Name: "waterdrop"})
+		assert.Nil(t, err)
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		defer srv.Reset()
+		srv.Inject("SayHelloURL", &Fault{Panic: "boom"})
+		_, err := rpc.SayHelloURL(context.Background(), &demo.HelloReq{Name: "waterdrop"})
+		assert.Equal(t, status.ServerErr.Code(), status.ExtractStatus(err).Code())
+	})
+}
+
+type service struct{}
+
+func (s *service) SayHello(ctx context.Context, req *demo.HelloReq) (reply *emptypb.Empty, err error) {
+	return &emptypb.Empty{}, nil
+}
+
+func (s *service) SayHelloURL(ctx context.Context, req *demo.HelloReq) (reply *demo.HelloResp, err error) {
+	return &demo.HelloResp{Content: "Hello " + req.Name}, nil
+}
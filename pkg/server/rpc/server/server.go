@@ -31,6 +31,7 @@ import (
 	"github.com/UnderTreeTech/waterdrop/pkg/server/rpc/interceptors"
 
 	"google.golang.org/grpc/reflection"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
 
 	_ "github.com/UnderTreeTech/waterdrop/pkg/version"
 	"google.golang.org/grpc/keepalive"
@@ -91,7 +92,10 @@ func (s *Server) Start() net.Addr {
 		panic(fmt.Sprintf("grpc server: listen tcp fail,err msg %s", err.Error()))
 	}
 
-	reflection.Register(s.server)
+	// reflection is registered by the gateway if it serves the server before Start
+	if _, ok := s.server.GetServiceInfo()[reflectionpb.ServerReflection_ServiceDesc.ServiceName]; !ok {
+		reflection.Register(s.server)
+	}
 	go func() {
 		if err := s.server.Serve(listener); err != nil {
 			if err == grpc.ErrServerStopped {
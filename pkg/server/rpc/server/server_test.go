@@ -27,6 +27,7 @@ import (
 	"github.com/UnderTreeTech/waterdrop/pkg/server/rpc/config"
 
 	"github.com/stretchr/testify/assert"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
 	"google.golang.org/grpc/test/grpc_testing"
 
 	"github.com/UnderTreeTech/waterdrop/pkg/log"
@@ -43,7 +44,12 @@ func TestMain(m *testing.M) {
 
 func TestStart(t *testing.T) {
 	grpc_testing.RegisterTestServiceServer(srv.server, &grpc_testing.UnimplementedTestServiceServer{})
+	// reflection is registered on Start
+	_, ok := srv.server.GetServiceInfo()[reflectionpb.ServerReflection_ServiceDesc.ServiceName]
+	assert.False(t, ok)
 	net := srv.Start()
+	_, ok = srv.server.GetServiceInfo()[reflectionpb.ServerReflection_ServiceDesc.ServiceName]
+	assert.True(t, ok)
 	assert.Equal(t, "[::]:20812", net.String())
 	assert.Equal(t, "tcp", net.Network())
 }
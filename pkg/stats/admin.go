@@ -0,0 +1,86 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package stats
+
+import (
+	"html/template"
+	"net/http"
+
+	"github.com/UnderTreeTech/waterdrop/pkg/stats/admin"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	admin.Describe(http.MethodGet, "/debug/profile/", "pprof profiles")
+	admin.Describe(http.MethodGet, "/metrics/", "prometheus metrics")
+}
+
+// route index page item
+type route struct {
+	Method      string
+	Path        string
+	Description string
+}
+
+var indexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html>
+<head><title>stats</title></head>
+<body>
+<table>
+<tr><th>Method</th><th>Path</th><th>Description</th></tr>
+{{range .}}<tr><td>{{.Method}}</td><td>{{if eq .Method "GET"}}<a href="{{.Path}}">{{.Path}}</a>{{else}}{{.Path}}{{end}}</td><td>{{.Description}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// registerAdmin mounts admin handlers and the index page listing all routes.
+// Protected handlers are skipped unless the stats server is protected
+func registerAdmin(engine *gin.Engine, protected bool) {
+	descriptions := make(map[string]string)
+	for _, endpoint := range admin.Endpoints() {
+		descriptions[endpoint.Method+" "+endpoint.Path] = endpoint.Description
+		if endpoint.Protected && !protected {
+			continue
+		}
+		if endpoint.Handler != nil {
+			engine.Handle(endpoint.Method, endpoint.Path, endpoint.Handler)
+		}
+	}
+
+	engine.GET("/", func(c *gin.Context) {
+		routes := make([]route, 0)
+		for _, info := range engine.Routes() {
+			if info.Path == "/" {
+				continue
+			}
+			routes = append(routes, route{
+				Method:      info.Method,
+				Path:        info.Path,
+				Description: descriptions[info.Method+" "+info.Path],
+			})
+		}
+
+		c.Header("Content-Type", "text/html; charset=utf-8")
+		if err := indexTemplate.Execute(c.Writer, routes); err != nil {
+			c.Status(http.StatusInternalServerError)
+		}
+	})
+}
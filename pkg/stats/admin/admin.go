@@ -0,0 +1,88 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// Package admin is the registry of admin handlers mounted on the stats server.
+// It depends on nothing but gin, so any package is able to register its handlers
+// without importing the stats server.
+package admin
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Endpoint admin endpoint
+type Endpoint struct {
+	Method      string
+	Path        string
+	Description string
+	Handler     gin.HandlerFunc
+	// Protected endpoint is only mounted when the stats server is protected by
+	// basic auth or ip allow-list
+	Protected bool
+}
+
+var (
+	endpoints = make(map[string]*Endpoint)
+	mutex     sync.RWMutex
+)
+
+// Handle register an admin handler, it must be called before the stats server starts.
+// Handler registered with the same method and path is replaced
+func Handle(method string, path string, description string, handler gin.HandlerFunc) {
+	handle(&Endpoint{Method: method, Path: path, Description: description, Handler: handler})
+}
+
+// HandleProtected register an admin handler exposing sensitive data or changing the
+// process state, it's only mounted when the stats server is protected
+func HandleProtected(method string, path string, description string, handler gin.HandlerFunc) {
+	handle(&Endpoint{Method: method, Path: path, Description: description, Handler: handler, Protected: true})
+}
+
+// handle register the endpoint
+func handle(endpoint *Endpoint) {
+	mutex.Lock()
+	endpoints[endpoint.Method+" "+endpoint.Path] = endpoint
+	mutex.Unlock()
+}
+
+// Describe register description of the endpoint, which is mounted by other ways,
+// e.g. pprof handlers registered on the engine directly
+func Describe(method string, path string, description string) {
+	Handle(method, path, description, nil)
+}
+
+// Endpoints returns registered endpoints sorted by path and method
+func Endpoints() []*Endpoint {
+	mutex.RLock()
+	list := make([]*Endpoint, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		list = append(list, endpoint)
+	}
+	mutex.RUnlock()
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Path == list[j].Path {
+			return list[i].Method < list[j].Method
+		}
+		return list[i].Path < list[j].Path
+	})
+	return list
+}
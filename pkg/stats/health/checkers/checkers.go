@@ -0,0 +1,112 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// Package checkers provides health checkers of the clients, such as databases, etcd and kafka
+package checkers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/UnderTreeTech/waterdrop/pkg/database/es"
+	"github.com/UnderTreeTech/waterdrop/pkg/database/mongo"
+	"github.com/UnderTreeTech/waterdrop/pkg/database/redis"
+	"github.com/UnderTreeTech/waterdrop/pkg/database/sql"
+	"github.com/UnderTreeTech/waterdrop/pkg/registry/etcd"
+	"github.com/UnderTreeTech/waterdrop/pkg/stats/health"
+
+	"github.com/Shopify/sarama"
+)
+
+// SQL returns a checker pings the master and all replicas of the db
+func SQL(db *sql.DB) health.Checker {
+	return health.CheckerFunc(db.Ping)
+}
+
+// Redis returns a checker pings the redis
+func Redis(r *redis.Redis) health.Checker {
+	return health.CheckerFunc(func(ctx context.Context) error {
+		if !r.Ping(ctx) {
+			return errors.New("redis ping fail")
+		}
+		return nil
+	})
+}
+
+// Mongo returns a checker pings the mongo
+func Mongo(db *mongo.DB) health.Checker {
+	return health.CheckerFunc(func(ctx context.Context) error {
+		return db.Ping()
+	})
+}
+
+// ES returns a checker pings the elasticsearch
+func ES(client *es.Client) health.Checker {
+	return health.CheckerFunc(func(ctx context.Context) error {
+		alive, err := client.Ping(ctx)
+		if err != nil {
+			return err
+		}
+		if !alive {
+			return errors.New("elasticsearch is not alive")
+		}
+		return nil
+	})
+}
+
+// Etcd returns a checker verifies the etcd session of the registry
+func Etcd(registry *etcd.EtcdRegistry) health.Checker {
+	return health.CheckerFunc(registry.Ping)
+}
+
+// Kafka returns a checker verifies connectivity to kafka brokers, it's up if any of the
+// brokers is connected. SASL settings of config are used if it's not nil
+func Kafka(addrs []string, config *sarama.Config) health.Checker {
+	return health.CheckerFunc(func(ctx context.Context) error {
+		cfg := sarama.NewConfig()
+		if config != nil {
+			copied := *config
+			cfg = &copied
+		}
+		if deadline, ok := ctx.Deadline(); ok {
+			cfg.Net.DialTimeout = time.Until(deadline)
+		}
+
+		var err error
+		for _, addr := range addrs {
+			broker := sarama.NewBroker(addr)
+			if err = broker.Open(cfg); err != nil {
+				continue
+			}
+
+			var connected bool
+			connected, err = broker.Connected()
+			_ = broker.Close()
+			if connected {
+				return nil
+			}
+		}
+
+		if err == nil {
+			err = errors.New("no broker connected")
+		}
+		return fmt.Errorf("kafka brokers %v unreachable: %w", addrs, err)
+	})
+}
@@ -0,0 +1,244 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package health
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/UnderTreeTech/waterdrop/pkg/stats/admin"
+
+	"github.com/gin-gonic/gin"
+)
+
+// check status
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+const (
+	// defaultTimeout default timeout of a check
+	defaultTimeout = time.Second
+	// defaultCacheTTL default duration a check result is cached
+	defaultCacheTTL = time.Second
+)
+
+// Checker checks whether a dependency is healthy
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc is an adapter to allow the use of ordinary functions as Checker
+type CheckerFunc func(ctx context.Context) error
+
+// Check calls f(ctx)
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Result result of a check
+type Result struct {
+	Status string `json:"status"`
+	// Latency check latency in milliseconds
+	Latency   float64   `json:"latency_ms"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// Report readiness report, status is up only if all checks are up
+type Report struct {
+	Status string             `json:"status"`
+	Checks map[string]*Result `json:"checks"`
+}
+
+// Option check option
+type Option func(*check)
+
+// Timeout set timeout of the check, default 1s
+func Timeout(timeout time.Duration) Option {
+	return func(c *check) {
+		c.timeout = timeout
+	}
+}
+
+// CacheTTL set duration the check result is cached, default 1s.
+// Readiness probes within it share the same result and won't hit the dependency
+func CacheTTL(ttl time.Duration) Option {
+	return func(c *check) {
+		c.ttl = ttl
+	}
+}
+
+// check a registered checker with its cached result
+type check struct {
+	name    string
+	checker Checker
+	timeout time.Duration
+	ttl     time.Duration
+
+	mutex  sync.Mutex
+	result *Result
+}
+
+var (
+	checks = make(map[string]*check)
+	mutex  sync.RWMutex
+)
+
+func init() {
+	admin.Handle(http.MethodGet, "/health/live", "liveness probe", liveHandler)
+	admin.Handle(http.MethodGet, "/health/ready", "readiness probe with dependency checks", readyHandler)
+}
+
+// Register register a readiness checker with the name, the checker registered
+// with the same name is replaced
+func Register(name string, checker Checker, opts ...Option) {
+	c := &check{
+		name:    name,
+		checker: checker,
+		timeout: defaultTimeout,
+		ttl:     defaultCacheTTL,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	mutex.Lock()
+	checks[name] = c
+	mutex.Unlock()
+}
+
+// Deregister remove the readiness checker with the name
+func Deregister(name string) {
+	mutex.Lock()
+	delete(checks, name)
+	mutex.Unlock()
+}
+
+// Ready runs all registered checkers concurrently and returns the report
+func Ready(ctx context.Context) *Report {
+	mutex.RLock()
+	list := make([]*check, 0, len(checks))
+	for _, c := range checks {
+		list = append(list, c)
+	}
+	mutex.RUnlock()
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].name < list[j].name
+	})
+
+	results := make([]*Result, len(list))
+	var wg sync.WaitGroup
+	for i, c := range list {
+		wg.Add(1)
+		go func(i int, c *check) {
+			defer wg.Done()
+			results[i] = c.run(ctx)
+		}(i, c)
+	}
+	wg.Wait()
+
+	report := &Report{Status: StatusUp, Checks: make(map[string]*Result, len(list))}
+	for i, c := range list {
+		report.Checks[c.name] = results[i]
+		if results[i].Status != StatusUp {
+			report.Status = StatusDown
+		}
+	}
+	return report
+}
+
+// run returns the cached result if it's fresh, otherwise runs the checker
+// within timeout. Concurrent runs wait for the same check.
+func (c *check) run(parent context.Context) *Result {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.result != nil && time.Since(c.result.CheckedAt) < c.ttl {
+		return c.result
+	}
+
+	ctx, cancel := context.WithTimeout(parent, c.timeout)
+	defer cancel()
+
+	now := time.Now()
+	ch := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				ch <- fmt.Errorf("check panic: %v", r)
+			}
+		}()
+		ch <- c.checker.Check(ctx)
+	}()
+
+	// checkers ignoring ctx are also bounded by timeout
+	var err error
+	select {
+	case err = <-ch:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := &Result{
+		Status:    StatusUp,
+		Latency:   float64(time.Since(now).Microseconds()) / 1000,
+		CheckedAt: now,
+	}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+
+	// the result of an aborted probe isn't cached, so the next probe checks again
+	if parent.Err() == nil {
+		c.result = result
+	}
+	return result
+}
+
+// RegisterHealth register liveness and readiness handlers. Liveness is always up while
+// the process is serving, readiness replies 503 if any registered check is down
+func RegisterHealth(engine *gin.Engine) {
+	health := engine.Group("/health")
+	{
+		health.GET("/live", liveHandler)
+		health.GET("/ready", readyHandler)
+	}
+}
+
+// liveHandler replies liveness
+func liveHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": StatusUp})
+}
+
+// readyHandler replies readiness report
+func readyHandler(c *gin.Context) {
+	report := Ready(c.Request.Context())
+	code := http.StatusOK
+	if report.Status != StatusUp {
+		code = http.StatusServiceUnavailable
+	}
+	c.JSON(code, report)
+}
@@ -0,0 +1,121 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReady(t *testing.T) {
+	var calls int32
+	Register("up", CheckerFunc(func(ctx context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		return nil
+	}), CacheTTL(time.Minute))
+	defer Deregister("up")
+
+	report := Ready(context.Background())
+	assert.Equal(t, StatusUp, report.Status)
+	assert.Equal(t, StatusUp, report.Checks["up"].Status)
+
+	// results are cached
+	Ready(context.Background())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	Register("down", CheckerFunc(func(ctx context.Context) error {
+		return errors.New("connection refused")
+	}))
+	Register("slow", CheckerFunc(func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	}), Timeout(10*time.Millisecond))
+	Register("panic", CheckerFunc(func(ctx context.Context) error {
+		panic("oops")
+	}))
+	defer Deregister("down")
+	defer Deregister("slow")
+	defer Deregister("panic")
+
+	now := time.Now()
+	report = Ready(context.Background())
+	assert.True(t, time.Since(now) < 500*time.Millisecond)
+	assert.Equal(t, StatusDown, report.Status)
+	assert.Equal(t, StatusUp, report.Checks["up"].Status)
+	assert.Equal(t, "connection refused", report.Checks["down"].Error)
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks["slow"].Error)
+	assert.Equal(t, "check panic: oops", report.Checks["panic"].Error)
+}
+
+func TestRegisterHealth(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	engine := gin.New()
+	RegisterHealth(engine)
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health/live", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	Register("down", CheckerFunc(func(ctx context.Context) error {
+		return errors.New("unreachable")
+	}))
+	defer Deregister("down")
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	report := &Report{}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), report))
+	assert.Equal(t, StatusDown, report.Status)
+	assert.Equal(t, StatusDown, report.Checks["down"].Status)
+	assert.Contains(t, report.Checks["down"].Error, "unreachable")
+}
+
+func TestReadyCanceled(t *testing.T) {
+	var calls int32
+	Register("cancel", CheckerFunc(func(ctx context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		<-ctx.Done()
+		return ctx.Err()
+	}), CacheTTL(time.Minute), Timeout(10*time.Millisecond))
+	defer Deregister("cancel")
+
+	// the result of an aborted probe isn't cached
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	report := Ready(ctx)
+	assert.Equal(t, context.Canceled.Error(), report.Checks["cancel"].Error)
+
+	report = Ready(context.Background())
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks["cancel"].Error)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
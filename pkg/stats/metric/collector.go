@@ -0,0 +1,218 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package metric
+
+import (
+	"database/sql"
+	"errors"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const _sqlPoolNamespace = "sql"
+
+// PoolStats redis connection pool stats
+type PoolStats struct {
+	// Hits number of times free connection was found in the pool
+	Hits uint64
+	// Misses number of times free connection was NOT found in the pool
+	Misses uint64
+	// Timeouts number of times a wait timeout occurred
+	Timeouts uint64
+	// TotalConns number of total connections in the pool
+	TotalConns uint64
+	// IdleConns number of idle connections in the pool
+	IdleConns uint64
+	// StaleConns number of stale connections removed from the pool
+	StaleConns uint64
+}
+
+var (
+	sqlPools   = newSQLCollector()
+	redisPools = newRedisCollector()
+)
+
+func init() {
+	// default registry registers go runtime and process collectors already,
+	// register them explicitly in case the defaults change
+	registerCollector(prometheus.NewGoCollector())
+	registerCollector(prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}))
+	registerCollector(sqlPools)
+	registerCollector(redisPools)
+}
+
+// registerCollector registers collector, it's ok if it has been registered
+func registerCollector(c prometheus.Collector) {
+	err := prometheus.Register(c)
+	if err == nil {
+		return
+	}
+
+	var are prometheus.AlreadyRegisteredError
+	if !errors.As(err, &are) {
+		panic(err)
+	}
+}
+
+// poolKey identifies a pool by name and addr
+type poolKey struct {
+	name string
+	addr string
+}
+
+// RegisterSQLStats registers a sql pool, stats is called on every scrape
+func RegisterSQLStats(name string, addr string, stats func() sql.DBStats) {
+	sqlPools.mutex.Lock()
+	sqlPools.pools[poolKey{name: name, addr: addr}] = stats
+	sqlPools.mutex.Unlock()
+}
+
+// UnregisterSQLStats unregisters a sql pool
+func UnregisterSQLStats(name string, addr string) {
+	sqlPools.mutex.Lock()
+	delete(sqlPools.pools, poolKey{name: name, addr: addr})
+	sqlPools.mutex.Unlock()
+}
+
+// RegisterRedisStats registers a redis pool, stats is called on every scrape
+func RegisterRedisStats(name string, addr string, stats func() PoolStats) {
+	redisPools.mutex.Lock()
+	redisPools.pools[poolKey{name: name, addr: addr}] = stats
+	redisPools.mutex.Unlock()
+}
+
+// UnregisterRedisStats unregisters a redis pool
+func UnregisterRedisStats(name string, addr string) {
+	redisPools.mutex.Lock()
+	delete(redisPools.pools, poolKey{name: name, addr: addr})
+	redisPools.mutex.Unlock()
+}
+
+// sqlCollector collects sql.DBStats of registered pools
+type sqlCollector struct {
+	mutex sync.RWMutex
+	pools map[poolKey]func() sql.DBStats
+
+	maxOpen      *prometheus.Desc
+	open         *prometheus.Desc
+	inUse        *prometheus.Desc
+	idle         *prometheus.Desc
+	waitCount    *prometheus.Desc
+	waitDuration *prometheus.Desc
+}
+
+func newSQLCollector() *sqlCollector {
+	labels := []string{"name", "addr"}
+	desc := func(name string, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(_sqlPoolNamespace, "pool", name), help, labels, nil)
+	}
+
+	return &sqlCollector{
+		pools:        make(map[poolKey]func() sql.DBStats),
+		maxOpen:      desc("max_open_connections", "sql pool max open connections."),
+		open:         desc("open_connections", "sql pool established connections, both in use and idle."),
+		inUse:        desc("in_use_connections", "sql pool connections currently in use."),
+		idle:         desc("idle_connections", "sql pool idle connections."),
+		waitCount:    desc("wait_total", "sql pool total number of connections waited for."),
+		waitDuration: desc("wait_duration_seconds_total", "sql pool total time blocked waiting for a new connection."),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *sqlCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.maxOpen
+	ch <- c.open
+	ch <- c.inUse
+	ch <- c.idle
+	ch <- c.waitCount
+	ch <- c.waitDuration
+}
+
+// Collect implements prometheus.Collector
+func (c *sqlCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	for key, stats := range c.pools {
+		s := stats()
+		ch <- prometheus.MustNewConstMetric(c.maxOpen, prometheus.GaugeValue, float64(s.MaxOpenConnections), key.name, key.addr)
+		ch <- prometheus.MustNewConstMetric(c.open, prometheus.GaugeValue, float64(s.OpenConnections), key.name, key.addr)
+		ch <- prometheus.MustNewConstMetric(c.inUse, prometheus.GaugeValue, float64(s.InUse), key.name, key.addr)
+		ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(s.Idle), key.name, key.addr)
+		ch <- prometheus.MustNewConstMetric(c.waitCount, prometheus.CounterValue, float64(s.WaitCount), key.name, key.addr)
+		ch <- prometheus.MustNewConstMetric(c.waitDuration, prometheus.CounterValue, s.WaitDuration.Seconds(), key.name, key.addr)
+	}
+}
+
+// redisCollector collects PoolStats of registered pools
+type redisCollector struct {
+	mutex sync.RWMutex
+	pools map[poolKey]func() PoolStats
+
+	hits     *prometheus.Desc
+	misses   *prometheus.Desc
+	timeouts *prometheus.Desc
+	total    *prometheus.Desc
+	idle     *prometheus.Desc
+	stale    *prometheus.Desc
+}
+
+func newRedisCollector() *redisCollector {
+	labels := []string{"name", "addr"}
+	desc := func(name string, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(_redisClientNamespace, "pool", name), help, labels, nil)
+	}
+
+	return &redisCollector{
+		pools:    make(map[poolKey]func() PoolStats),
+		hits:     desc("hits_total", "redis pool free connection found times."),
+		misses:   desc("misses_total", "redis pool free connection not found times."),
+		timeouts: desc("timeouts_total", "redis pool wait timeout times."),
+		total:    desc("total_connections", "redis pool total connections."),
+		idle:     desc("idle_connections", "redis pool idle connections."),
+		stale:    desc("stale_connections_total", "redis pool stale connections removed."),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *redisCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.hits
+	ch <- c.misses
+	ch <- c.timeouts
+	ch <- c.total
+	ch <- c.idle
+	ch <- c.stale
+}
+
+// Collect implements prometheus.Collector
+func (c *redisCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	for key, stats := range c.pools {
+		s := stats()
+		ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(s.Hits), key.name, key.addr)
+		ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(s.Misses), key.name, key.addr)
+		ch <- prometheus.MustNewConstMetric(c.timeouts, prometheus.CounterValue, float64(s.Timeouts), key.name, key.addr)
+		ch <- prometheus.MustNewConstMetric(c.total, prometheus.GaugeValue, float64(s.TotalConns), key.name, key.addr)
+		ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(s.IdleConns), key.name, key.addr)
+		ch <- prometheus.MustNewConstMetric(c.stale, prometheus.CounterValue, float64(s.StaleConns), key.name, key.addr)
+	}
+}
@@ -0,0 +1,77 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package metric
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSQLCollector test sql pool stats collecting
+func TestSQLCollector(t *testing.T) {
+	RegisterSQLStats("test", "127.0.0.1:3306", func() sql.DBStats {
+		return sql.DBStats{MaxOpenConnections: 10, OpenConnections: 3, InUse: 2, Idle: 1, WaitCount: 5, WaitDuration: time.Second}
+	})
+	assert.Equal(t, 6, testutil.CollectAndCount(sqlPools))
+
+	expected := `
+# HELP sql_pool_in_use_connections sql pool connections currently in use.
+# TYPE sql_pool_in_use_connections gauge
+sql_pool_in_use_connections{addr="127.0.0.1:3306",name="test"} 2
+# HELP sql_pool_wait_duration_seconds_total sql pool total time blocked waiting for a new connection.
+# TYPE sql_pool_wait_duration_seconds_total counter
+sql_pool_wait_duration_seconds_total{addr="127.0.0.1:3306",name="test"} 1
+`
+	assert.Nil(t, testutil.CollectAndCompare(sqlPools, strings.NewReader(expected),
+		"sql_pool_in_use_connections", "sql_pool_wait_duration_seconds_total"))
+
+	UnregisterSQLStats("test", "127.0.0.1:3306")
+	assert.Equal(t, 0, testutil.CollectAndCount(sqlPools))
+}
+
+// TestRedisCollector test redis pool stats collecting
+func TestRedisCollector(t *testing.T) {
+	RegisterRedisStats("test", "127.0.0.1:6379", func() PoolStats {
+		return PoolStats{Hits: 8, Misses: 2, Timeouts: 1, TotalConns: 4, IdleConns: 3}
+	})
+	assert.Equal(t, 6, testutil.CollectAndCount(redisPools))
+
+	expected := `
+# HELP redis_pool_hits_total redis pool free connection found times.
+# TYPE redis_pool_hits_total counter
+redis_pool_hits_total{addr="127.0.0.1:6379",name="test"} 8
+`
+	assert.Nil(t, testutil.CollectAndCompare(redisPools, strings.NewReader(expected), "redis_pool_hits_total"))
+
+	UnregisterRedisStats("test", "127.0.0.1:6379")
+	assert.Equal(t, 0, testutil.CollectAndCount(redisPools))
+}
+
+// TestRuntimeCollector test go runtime and process metrics are exported
+func TestRuntimeCollector(t *testing.T) {
+	count, err := testutil.GatherAndCount(prometheus.DefaultGatherer, "go_goroutines", "go_gc_duration_seconds")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, count)
+}
@@ -20,7 +20,10 @@ package metric
 
 const (
 	_httpServerNamespace  = "http_server"
+	_httpClientNamespace  = "http_client"
+	_websocketNamespace   = "websocket"
 	_unaryServerNamespace = "unary_server"
+	_unaryClientNamespace = "unary_client"
 
 	_redisClientNamespace = "redis"
 	_mysqlClientNamespace = "mysql"
@@ -49,6 +52,50 @@ var (
 		Help:      "http server requests error count.",
 		Labels:    []string{"path", "method", "peer", "code"},
 	})
+
+	HTTPClientReqDuration = NewHistogramVec(&HistogramVecOpts{
+		Namespace: _httpClientNamespace,
+		Subsystem: "requests",
+		Name:      "duration_ms",
+		Help:      "http client requests duration(ms).",
+		Labels:    []string{"host", "method", "route"},
+		Buckets:   []float64{5, 10, 25, 50, 100, 250, 500, 1000},
+	})
+
+	HTTPClientHandleCounter = NewCounterVec(&CounterVecOpts{
+		Namespace: _httpClientNamespace,
+		Subsystem: "requests",
+		Name:      "code_total",
+		Help:      "http client requests code count.",
+		Labels:    []string{"host", "method", "route", "code"},
+	})
+)
+
+// websocket metrics
+var (
+	WebSocketConnections = NewGaugeVec(&GaugeVecOpts{
+		Namespace: _websocketNamespace,
+		Subsystem: "connections",
+		Name:      "current",
+		Help:      "websocket current connections.",
+		Labels:    []string{"path"},
+	})
+
+	WebSocketMessageCounter = NewCounterVec(&CounterVecOpts{
+		Namespace: _websocketNamespace,
+		Subsystem: "messages",
+		Name:      "total",
+		Help:      "websocket messages count.",
+		Labels:    []string{"path", "direction"},
+	})
+
+	WebSocketDropCounter = NewCounterVec(&CounterVecOpts{
+		Namespace: _websocketNamespace,
+		Subsystem: "messages",
+		Name:      "dropped_total",
+		Help:      "websocket messages dropped for slow or closed sessions.",
+		Labels:    []string{"path"},
+	})
 )
 
 // unary metrics
@@ -69,6 +116,31 @@ var (
 		Help:      "unary server requests error count.",
 		Labels:    []string{"peer", "method", "code"},
 	})
+
+	UnaryClientReqDuration = NewHistogramVec(&HistogramVecOpts{
+		Namespace: _unaryClientNamespace,
+		Subsystem: "requests",
+		Name:      "duration_ms",
+		Help:      "unary client requests duration(ms).",
+		Labels:    []string{"target", "method"},
+		Buckets:   []float64{5, 10, 25, 50, 100, 250, 500, 1000},
+	})
+
+	UnaryClientHandleCounter = NewCounterVec(&CounterVecOpts{
+		Namespace: _unaryClientNamespace,
+		Subsystem: "requests",
+		Name:      "code_total",
+		Help:      "unary client requests code count.",
+		Labels:    []string{"target", "method", "code"},
+	})
+
+	UnaryClientInflight = NewGaugeVec(&GaugeVecOpts{
+		Namespace: _unaryClientNamespace,
+		Subsystem: "requests",
+		Name:      "in_flight",
+		Help:      "unary client requests in flight.",
+		Labels:    []string{"target", "method"},
+	})
 )
 
 // redis metrics
@@ -189,4 +261,20 @@ var (
 		Labels:    []string{"peer", "type", "name", "command"},
 		Buckets:   []float64{5, 10, 25, 50, 100, 250, 500, 1000},
 	})
+
+	KafkaConsumerLag = NewGaugeVec(&GaugeVecOpts{
+		Namespace: _kafkaClientNamespace,
+		Subsystem: "consumer",
+		Name:      "lag",
+		Help:      "kafka consumer lag, messages behind the partition high water mark.",
+		Labels:    []string{"group", "topic", "partition"},
+	})
+
+	KafkaProducerInflight = NewGaugeVec(&GaugeVecOpts{
+		Namespace: _kafkaClientNamespace,
+		Subsystem: "producer",
+		Name:      "in_flight",
+		Help:      "kafka producer messages sent but not acked yet.",
+		Labels:    []string{"topic"},
+	})
 )
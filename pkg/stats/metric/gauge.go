@@ -66,3 +66,8 @@ func (g *gaugeVec) Add(v float64, labels ...string) {
 func (g *gaugeVec) Sub(v float64, labels ...string) {
 	g.WithLabelValues(labels...).Sub(v)
 }
+
+// Set sets the Gauge to an arbitrary value
+func (g *gaugeVec) Set(v float64, labels ...string) {
+	g.WithLabelValues(labels...).Set(v)
+}
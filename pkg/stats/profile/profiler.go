@@ -0,0 +1,307 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package profile
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/UnderTreeTech/waterdrop/pkg/log"
+)
+
+// profile kinds
+const (
+	KindCPU       = "cpu"
+	KindHeap      = "heap"
+	KindGoroutine = "goroutine"
+)
+
+// ProfilerConfig threshold profiler config, zero threshold disables the trigger
+type ProfilerConfig struct {
+	// Dir directory where profiles are dumped, default $TMPDIR/waterdrop-profile
+	Dir string
+	// Interval sampling interval, default 5s
+	Interval time.Duration
+	// CPUThreshold cpu usage percent of GOMAXPROCS cores, e.g. 80, triggers cpu profile
+	CPUThreshold float64
+	// HeapThreshold heap in-use bytes, triggers heap profile
+	HeapThreshold uint64
+	// GoroutineThreshold goroutine count, triggers goroutine profile
+	GoroutineThreshold int
+	// GoroutineGrowth ratio of goroutine count to its moving average, e.g. 2 means
+	// doubling, triggers goroutine profile
+	GoroutineGrowth float64
+	// GCPauseThreshold max gc pause in an interval, triggers heap profile
+	GCPauseThreshold time.Duration
+	// CPUProfileDuration duration of cpu profiling, default 10s
+	CPUProfileDuration time.Duration
+	// Cooldown min duration between two dumps of the same kind, default 5m
+	Cooldown time.Duration
+	// MaxFiles max dumps kept for each kind, older ones are removed, default 10
+	MaxFiles int
+}
+
+// Sample runtime sample
+type Sample struct {
+	// CPU cpu usage percent of GOMAXPROCS cores
+	CPU        float64
+	HeapInuse  uint64
+	Goroutines int
+	// GCPause max gc pause since last sample
+	GCPause time.Duration
+	Time    time.Time
+}
+
+// Profiler samples runtime periodically, and dumps profiles once thresholds are crossed
+type Profiler struct {
+	config *ProfilerConfig
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mutex     sync.Mutex
+	last      Sample
+	cpuTime   time.Duration
+	numGC     uint32
+	baseline  float64
+	dumpedAt  map[string]time.Time
+	profiling bool
+}
+
+// NewProfiler returns a threshold profiler
+func NewProfiler(config *ProfilerConfig) *Profiler {
+	if config.Dir == "" {
+		config.Dir = filepath.Join(os.TempDir(), "waterdrop-profile")
+	}
+	if config.Interval <= 0 {
+		config.Interval = 5 * time.Second
+	}
+	if config.CPUProfileDuration <= 0 {
+		config.CPUProfileDuration = 10 * time.Second
+	}
+	if config.Cooldown <= 0 {
+		config.Cooldown = 5 * time.Minute
+	}
+	if config.MaxFiles <= 0 {
+		config.MaxFiles = 10
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Profiler{
+		config:   config,
+		ctx:      ctx,
+		cancel:   cancel,
+		dumpedAt: make(map[string]time.Time),
+	}
+}
+
+// Start starts sampling in background
+func (p *Profiler) Start() error {
+	if err := os.MkdirAll(p.config.Dir, 0755); err != nil {
+		return err
+	}
+
+	p.sample()
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		ticker := time.NewTicker(p.config.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-p.ctx.Done():
+				return
+			case <-ticker.C:
+				p.check(p.sample())
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop stops sampling and the running cpu profiling
+func (p *Profiler) Stop() {
+	p.cancel()
+	p.wg.Wait()
+}
+
+// Last returns the last sample
+func (p *Profiler) Last() Sample {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.last
+}
+
+// sample samples runtime, cpu usage is calculated from process cpu time since last sample
+func (p *Profiler) sample() Sample {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	now := time.Now()
+	cpuTime := processCPUTime()
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	s := Sample{
+		HeapInuse:  ms.HeapInuse,
+		Goroutines: runtime.NumGoroutine(),
+		Time:       now,
+	}
+
+	if !p.last.Time.IsZero() {
+		if wall := now.Sub(p.last.Time); wall > 0 {
+			s.CPU = float64(cpuTime-p.cpuTime) / float64(wall) / float64(runtime.GOMAXPROCS(0)) * 100
+		}
+
+		// PauseNs is a circular buffer of recent 256 gc pauses
+		for n := p.numGC; n < ms.NumGC && ms.NumGC-n <= uint32(len(ms.PauseNs)); n++ {
+			if pause := time.Duration(ms.PauseNs[n%uint32(len(ms.PauseNs))]); pause > s.GCPause {
+				s.GCPause = pause
+			}
+		}
+	}
+
+	p.last = s
+	p.cpuTime = cpuTime
+	p.numGC = ms.NumGC
+	return s
+}
+
+// check dumps profiles if the sample crosses thresholds
+func (p *Profiler) check(s Sample) {
+	cfg := p.config
+	if cfg.CPUThreshold > 0 && s.CPU > cfg.CPUThreshold {
+		p.trigger(KindCPU, fmt.Sprintf("cpu %.2f%% > %.2f%%", s.CPU, cfg.CPUThreshold))
+	}
+
+	if cfg.HeapThreshold > 0 && s.HeapInuse > cfg.HeapThreshold {
+		p.trigger(KindHeap, fmt.Sprintf("heap inuse %d > %d", s.HeapInuse, cfg.HeapThreshold))
+	}
+	if cfg.GCPauseThreshold > 0 && s.GCPause > cfg.GCPauseThreshold {
+		p.trigger(KindHeap, fmt.Sprintf("gc pause %s > %s", s.GCPause, cfg.GCPauseThreshold))
+	}
+
+	if cfg.GoroutineThreshold > 0 && s.Goroutines > cfg.GoroutineThreshold {
+		p.trigger(KindGoroutine, fmt.Sprintf("goroutines %d > %d", s.Goroutines, cfg.GoroutineThreshold))
+	}
+
+	// baseline is the moving average of goroutine count, so slow growth is not a spike
+	p.mutex.Lock()
+	baseline := p.baseline
+	if baseline == 0 {
+		p.baseline = float64(s.Goroutines)
+	} else {
+		p.baseline = 0.9*baseline + 0.1*float64(s.Goroutines)
+	}
+	p.mutex.Unlock()
+
+	if cfg.GoroutineGrowth > 0 && baseline > 0 && float64(s.Goroutines) >= baseline*cfg.GoroutineGrowth {
+		p.trigger(KindGoroutine, fmt.Sprintf("goroutines %d >= %.0f x %.2f", s.Goroutines, baseline, cfg.GoroutineGrowth))
+	}
+}
+
+// trigger dumps the profile unless it's in cooldown
+func (p *Profiler) trigger(kind string, reason string) {
+	p.mutex.Lock()
+	if time.Since(p.dumpedAt[kind]) < p.config.Cooldown || (kind == KindCPU && p.profiling) {
+		p.mutex.Unlock()
+		return
+	}
+	p.dumpedAt[kind] = time.Now()
+	if kind == KindCPU {
+		p.profiling = true
+	}
+	p.mutex.Unlock()
+
+	if kind != KindCPU {
+		p.dump(kind, reason)
+		return
+	}
+
+	// cpu profiling lasts for a while, don't block sampling
+	p.wg.Add(1)
+	go func() {
+		defer func() {
+			p.mutex.Lock()
+			p.profiling = false
+			p.mutex.Unlock()
+			p.wg.Done()
+		}()
+		p.dump(kind, reason)
+	}()
+}
+
+// dump writes the profile to file, rotates old files and logs the file path
+func (p *Profiler) dump(kind string, reason string) {
+	file := filepath.Join(p.config.Dir, fmt.Sprintf("%s-%s.pprof", kind, time.Now().Format("20060102-150405.000")))
+	f, err := os.Create(file)
+	if err != nil {
+		log.Errorf("create profile fail", log.String("kind", kind), log.String("error", err.Error()))
+		return
+	}
+
+	switch kind {
+	case KindCPU:
+		if err = pprof.StartCPUProfile(f); err == nil {
+			select {
+			case <-p.ctx.Done():
+			case <-time.After(p.config.CPUProfileDuration):
+			}
+			pprof.StopCPUProfile()
+		}
+	case KindHeap:
+		err = pprof.WriteHeapProfile(f)
+	case KindGoroutine:
+		err = pprof.Lookup("goroutine").WriteTo(f, 0)
+	}
+	f.Close()
+
+	if err != nil {
+		os.Remove(file)
+		log.Errorf("dump profile fail", log.String("kind", kind), log.String("reason", reason), log.String("error", err.Error()))
+		return
+	}
+
+	log.Warnf("profile dumped", log.String("kind", kind), log.String("reason", reason), log.String("file", file))
+	p.rotate(kind)
+}
+
+// rotate removes the oldest dumps of the kind beyond MaxFiles
+func (p *Profiler) rotate(kind string) {
+	files, err := filepath.Glob(filepath.Join(p.config.Dir, kind+"-*.pprof"))
+	if err != nil || len(files) <= p.config.MaxFiles {
+		return
+	}
+
+	// file names are ordered by time
+	sort.Strings(files)
+	for _, file := range files[:len(files)-p.config.MaxFiles] {
+		if err := os.Remove(file); err != nil && !strings.Contains(err.Error(), "no such file") {
+			log.Warnf("remove profile fail", log.String("file", file), log.String("error", err.Error()))
+		}
+	}
+}
@@ -0,0 +1,84 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package profile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/UnderTreeTech/waterdrop/pkg/log"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	defer log.New(nil).Sync()
+	os.Exit(m.Run())
+}
+
+// TestProfilerSample test runtime sampling
+func TestProfilerSample(t *testing.T) {
+	p := NewProfiler(&ProfilerConfig{Dir: t.TempDir(), Interval: 10 * time.Millisecond})
+	assert.Nil(t, p.Start())
+	time.Sleep(50 * time.Millisecond)
+	p.Stop()
+
+	s := p.Last()
+	assert.True(t, s.Goroutines > 0)
+	assert.True(t, s.HeapInuse > 0)
+	assert.True(t, s.CPU >= 0)
+}
+
+// TestProfilerTrigger test thresholds, cooldown and rotation
+func TestProfilerTrigger(t *testing.T) {
+	dir := t.TempDir()
+	p := NewProfiler(&ProfilerConfig{
+		Dir:                dir,
+		CPUThreshold:       80,
+		HeapThreshold:      1024,
+		GoroutineGrowth:    2,
+		CPUProfileDuration: 10 * time.Millisecond,
+		Cooldown:           time.Millisecond,
+		MaxFiles:           2,
+	})
+
+	p.check(Sample{Goroutines: 10})
+	p.check(Sample{CPU: 90, Goroutines: 20})
+	p.Stop()
+	cpu, _ := filepath.Glob(filepath.Join(dir, "cpu-*.pprof"))
+	goroutine, _ := filepath.Glob(filepath.Join(dir, "goroutine-*.pprof"))
+	heap, _ := filepath.Glob(filepath.Join(dir, "heap-*.pprof"))
+	assert.Equal(t, 1, len(cpu))
+	assert.Equal(t, 1, len(goroutine))
+	assert.Equal(t, 0, len(heap))
+
+	for i := 0; i < 3; i++ {
+		time.Sleep(5 * time.Millisecond)
+		p.check(Sample{HeapInuse: 2048})
+	}
+	heap, _ = filepath.Glob(filepath.Join(dir, "heap-*.pprof"))
+	assert.Equal(t, 2, len(heap))
+
+	p.config.Cooldown = time.Hour
+	p.check(Sample{HeapInuse: 2048})
+	heap, _ = filepath.Glob(filepath.Join(dir, "heap-*.pprof"))
+	assert.Equal(t, 2, len(heap))
+}
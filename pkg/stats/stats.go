@@ -19,8 +19,13 @@
 package stats
 
 import (
+	"context"
+	"crypto/subtle"
 	"fmt"
 	"net"
+	"net/http"
+	"strings"
+	"sync"
 
 	"github.com/UnderTreeTech/waterdrop/pkg/registry"
 	"github.com/UnderTreeTech/waterdrop/pkg/utils/xnet"
@@ -31,30 +36,156 @@ import (
 	"github.com/UnderTreeTech/waterdrop/pkg/stats/profile"
 )
 
-// StartStats start stats server
+// Config stats server config. Protected admin handlers like config and log level
+// are only mounted when Username or AllowIPs is set
+type Config struct {
+	// Addr listen addr, like :9090 or 127.0.0.1:9090, default :0 which picks a random port
+	Addr string
+	// Username basic auth username, basic auth is enabled if it's set
+	Username string
+	// Password basic auth password
+	Password string
+	// AllowIPs ips or cidrs allowed to access, empty means allow all.
+	// It's matched against the peer address, forwarded headers are not trusted
+	AllowIPs []string
+}
+
+// DefaultConfig default stats server config
+func DefaultConfig() *Config {
+	return &Config{Addr: ":0"}
+}
+
+var (
+	server *http.Server
+	mutex  sync.Mutex
+)
+
+// StartStats start stats server on a random port without protection,
+// so protected admin handlers are not mounted
 func StartStats(serviceName string) (si *registry.ServiceInfo, err error) {
-	gin.SetMode("release")
-	engine := gin.Default()
+	return StartStatsWithConfig(serviceName, nil)
+}
 
-	profile.RegisterProfile(engine)
-	metric.RegisterMetric(engine)
-	listener, err := net.Listen("tcp", ":0")
+// StartStatsWithConfig start stats server with the config, it serves pprof, metrics
+// and admin handlers registered by admin.Handle, e.g. health probes, breaker and
+// sentinel stats, which are registered by their packages once imported
+func StartStatsWithConfig(serviceName string, config *Config) (si *registry.ServiceInfo, err error) {
+	if config == nil {
+		config = DefaultConfig()
+	}
+
+	handler, err := newHandler(config)
 	if err != nil {
 		return nil, err
 	}
 
+	listener, err := net.Listen("tcp", config.Addr)
+	if err != nil {
+		return nil, err
+	}
+
+	srv := &http.Server{Handler: handler}
+	mutex.Lock()
+	server = srv
+	mutex.Unlock()
+
 	go func() {
-		if err = engine.RunListener(listener); err != nil {
+		if err := srv.Serve(listener); err != nil {
 			return
 		}
 	}()
 
-	_, port, _ := net.SplitHostPort(listener.Addr().String())
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	if ip := net.ParseIP(host); ip == nil || ip.IsUnspecified() {
+		host = xnet.InternalIP()
+	}
 	si = &registry.ServiceInfo{
 		Name:    serviceName + "/stats",
 		Scheme:  "http",
-		Addr:    fmt.Sprintf("%s://%s:%s", "http", xnet.InternalIP(), port),
+		Addr:    fmt.Sprintf("%s://%s", "http", net.JoinHostPort(host, port)),
 		Version: "1.0.0",
 	}
 	return
 }
+
+// StopStats stop the stats server gracefully
+func StopStats(ctx context.Context) error {
+	mutex.Lock()
+	srv := server
+	server = nil
+	mutex.Unlock()
+
+	if srv == nil {
+		return nil
+	}
+	return srv.Shutdown(ctx)
+}
+
+// newHandler returns the protected stats handler
+func newHandler(config *Config) (http.Handler, error) {
+	allow, err := allowIPs(config.AllowIPs)
+	if err != nil {
+		return nil, err
+	}
+
+	gin.SetMode("release")
+	engine := gin.New()
+	engine.Use(gin.Recovery(), allow)
+	if config.Username != "" {
+		engine.Use(basicAuth(config.Username, config.Password))
+	}
+
+	profile.RegisterProfile(engine)
+	metric.RegisterMetric(engine)
+	registerAdmin(engine, config.Username != "" || len(config.AllowIPs) > 0)
+	return engine, nil
+}
+
+// allowIPs returns a middleware rejects requests whose peer address is not in the allow-list
+func allowIPs(ips []string) (gin.HandlerFunc, error) {
+	nets := make([]*net.IPNet, 0, len(ips))
+	for _, ip := range ips {
+		if !strings.Contains(ip, "/") {
+			if strings.Contains(ip, ":") {
+				ip += "/128"
+			} else {
+				ip += "/32"
+			}
+		}
+
+		_, ipnet, err := net.ParseCIDR(ip)
+		if err != nil {
+			return nil, fmt.Errorf("invalid stats allow ip %s: %w", ip, err)
+		}
+		nets = append(nets, ipnet)
+	}
+
+	return func(c *gin.Context) {
+		if len(nets) == 0 {
+			return
+		}
+
+		host, _, _ := net.SplitHostPort(c.Request.RemoteAddr)
+		if ip := net.ParseIP(host); ip != nil {
+			for _, ipnet := range nets {
+				if ipnet.Contains(ip) {
+					return
+				}
+			}
+		}
+		c.AbortWithStatus(http.StatusForbidden)
+	}, nil
+}
+
+// basicAuth returns a basic auth middleware, credentials are compared in constant time
+func basicAuth(username string, password string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, pass, ok := c.Request.BasicAuth()
+		if !ok ||
+			subtle.ConstantTimeCompare([]byte(user), []byte(username)) != 1 ||
+			subtle.ConstantTimeCompare([]byte(pass), []byte(password)) != 1 {
+			c.Header("WWW-Authenticate", `Basic realm="stats"`)
+			c.AbortWithStatus(http.StatusUnauthorized)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package stats
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/UnderTreeTech/waterdrop/pkg/log"
+	"github.com/UnderTreeTech/waterdrop/pkg/stats/admin"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	defer log.New(nil).Sync()
+	os.Exit(m.Run())
+}
+
+// serve serves the request with the stats handler
+func serve(t *testing.T, config *Config, req *http.Request) *httptest.ResponseRecorder {
+	handler, err := newHandler(config)
+	assert.Nil(t, err)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func TestStartStats(t *testing.T) {
+	si, err := StartStatsWithConfig("waterdrop", &Config{Addr: "127.0.0.1:0"})
+	assert.Nil(t, err)
+	assert.Equal(t, "waterdrop/stats", si.Name)
+	assert.True(t, strings.HasPrefix(si.Addr, "http://127.0.0.1:"))
+
+	resp, err := http.Get(si.Addr + "/metrics/")
+	assert.Nil(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	assert.Nil(t, StopStats(context.Background()))
+	_, err = http.Get(si.Addr + "/metrics/")
+	assert.NotNil(t, err)
+}
+
+func TestProtection(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/debug/profile/", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+
+	assert.Equal(t, http.StatusOK, serve(t, &Config{}, req).Code)
+	assert.Equal(t, http.StatusOK, serve(t, &Config{AllowIPs: []string{"127.0.0.1", "10.0.0.0/8"}}, req).Code)
+	assert.Equal(t, http.StatusForbidden, serve(t, &Config{AllowIPs: []string{"127.0.0.1"}}, req).Code)
+
+	// forwarded headers are not trusted
+	req.Header.Set("X-Forwarded-For", "127.0.0.1")
+	assert.Equal(t, http.StatusForbidden, serve(t, &Config{AllowIPs: []string{"127.0.0.1"}}, req).Code)
+
+	config := &Config{Username: "admin", Password: "pass"}
+	assert.Equal(t, http.StatusUnauthorized, serve(t, config, req).Code)
+	req.SetBasicAuth("admin", "wrong")
+	assert.Equal(t, http.StatusUnauthorized, serve(t, config, req).Code)
+	req.SetBasicAuth("admin", "pass")
+	assert.Equal(t, http.StatusOK, serve(t, config, req).Code)
+
+	_, err := newHandler(&Config{AllowIPs: []string{"localhost"}})
+	assert.NotNil(t, err)
+}
+
+func TestAdmin(t *testing.T) {
+	admin.Handle(http.MethodGet, "/debug/custom", "custom admin handler", func(c *gin.Context) {
+		c.String(http.StatusOK, "custom")
+	})
+	admin.HandleProtected(http.MethodGet, "/debug/secret", "protected admin handler", func(c *gin.Context) {
+		c.String(http.StatusOK, "secret")
+	})
+
+	w := serve(t, &Config{}, httptest.NewRequest(http.MethodGet, "/debug/custom", nil))
+	assert.Equal(t, "custom", w.Body.String())
+
+	w = serve(t, &Config{}, httptest.NewRequest(http.MethodGet, "/", nil))
+	body, _ := ioutil.ReadAll(w.Body)
+	assert.Contains(t, string(body), `<a href="/debug/custom">/debug/custom</a>`)
+	assert.Contains(t, string(body), "custom admin handler")
+	assert.Contains(t, string(body), "prometheus metrics")
+
+	// protected handlers are not mounted without protection
+	assert.NotContains(t, string(body), "/debug/secret")
+	w = serve(t, &Config{}, httptest.NewRequest(http.MethodPut, "/debug/log/level?level=error", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = serve(t, &Config{}, httptest.NewRequest(http.MethodGet, "/debug/secret", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	config := &Config{AllowIPs: []string{"192.0.2.1"}}
+	req := httptest.NewRequest(http.MethodPut, "/debug/log/level?level=error", nil)
+	w = serve(t, config, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "error", log.GetLevel())
+	req = httptest.NewRequest(http.MethodPut, "/debug/log/level?level=verbose", nil)
+	w = serve(t, config, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = serve(t, config, httptest.NewRequest(http.MethodGet, "/debug/secret", nil))
+	assert.Equal(t, "secret", w.Body.String())
+}
@@ -0,0 +1,66 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package status
+
+import (
+	"net/http"
+	"sync"
+)
+
+// statusClientClosedRequest the client closed the connection before server responds
+const statusClientClosedRequest = 499
+
+var _httpStatus sync.Map
+
+func init() {
+	RegisterHTTPStatus(OK, http.StatusOK)
+	RegisterHTTPStatus(RequestErr, http.StatusBadRequest)
+	RegisterHTTPStatus(Unauthorized, http.StatusUnauthorized)
+	RegisterHTTPStatus(AccessDenied, http.StatusForbidden)
+	RegisterHTTPStatus(NothingFound, http.StatusNotFound)
+	RegisterHTTPStatus(MethodNotAllowed, http.StatusMethodNotAllowed)
+	RegisterHTTPStatus(LimitExceed, http.StatusTooManyRequests)
+	RegisterHTTPStatus(Canceled, statusClientClosedRequest)
+	RegisterHTTPStatus(ServerErr, http.StatusInternalServerError)
+	RegisterHTTPStatus(ServiceUnavailable, http.StatusServiceUnavailable)
+	RegisterHTTPStatus(Deadline, http.StatusGatewayTimeout)
+	RegisterHTTPStatus(AppKeyInvalid, http.StatusUnauthorized)
+	RegisterHTTPStatus(SignCheckErr, http.StatusUnauthorized)
+	RegisterHTTPStatus(RepeatedRequest, http.StatusConflict)
+	RegisterHTTPStatus(CaptchaErr, http.StatusBadRequest)
+	RegisterHTTPStatus(TargetBlocked, http.StatusLocked)
+	RegisterHTTPStatus(PayloadTooLarge, http.StatusRequestEntityTooLarge)
+	RegisterHTTPStatus(ServiceUpdate, http.StatusServiceUnavailable)
+	RegisterHTTPStatus(UndefinedErr, http.StatusInternalServerError)
+}
+
+// RegisterHTTPStatus binds the status code to http status, it can be override.
+func RegisterHTTPStatus(s *Status, httpStatus int) {
+	_httpStatus.Store(s.Code(), httpStatus)
+}
+
+// HTTPStatus returns the http status of s, status whose code is not registered
+// by RegisterHTTPStatus is regarded as internal server error
+func (s *Status) HTTPStatus() int {
+	if httpStatus, ok := _httpStatus.Load(s.Code()); ok {
+		return httpStatus.(int)
+	}
+
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,37 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package status
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPStatus(t *testing.T) {
+	assert.Equal(t, http.StatusOK, OK.HTTPStatus())
+	assert.Equal(t, http.StatusNotFound, NothingFound.HTTPStatus())
+	assert.Equal(t, http.StatusGatewayTimeout, Deadline.HTTPStatus())
+
+	biz := New(10001, "user not found")
+	assert.Equal(t, http.StatusInternalServerError, biz.HTTPStatus())
+	RegisterHTTPStatus(biz, http.StatusNotFound)
+	assert.Equal(t, http.StatusNotFound, biz.HTTPStatus())
+}
@@ -0,0 +1,75 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package status
+
+import "sync"
+
+// _messages localized messages keyed by language, the value is a map of code to message
+var _messages sync.Map
+
+func init() {
+	RegisterMessages("en", map[int]string{
+		OK.Code():                 "ok",
+		RequestErr.Code():         "bad request",
+		Unauthorized.Code():       "unauthorized, please login first",
+		AccessDenied.Code():       "access denied",
+		NothingFound.Code():       "nothing found",
+		MethodNotAllowed.Code():   "method not allowed",
+		LimitExceed.Code():        "too many requests",
+		Canceled.Code():           "request canceled by client",
+		ServerErr.Code():          "network error, please try again later",
+		ServiceUnavailable.Code(): "service unavailable",
+		Deadline.Code():           "request timeout",
+		AppKeyInvalid.Code():      "appkey is invalid or blocked",
+		SignCheckErr.Code():       "signature check failed",
+		RepeatedRequest.Code():    "repeated request",
+		CaptchaErr.Code():         "captcha error",
+		TargetBlocked.Code():      "resource locked, please try again later",
+		PayloadTooLarge.Code():    "payload too large",
+		ServiceUpdate.Code():      "service upgrading",
+		UndefinedErr.Code():       "unknown error",
+	})
+}
+
+// RegisterMessages registers localized messages of codes for the language, such as en, ja.
+// Messages registered before are override.
+func RegisterMessages(lng string, messages map[int]string) {
+	merged := make(map[int]string)
+	if registered, ok := _messages.Load(lng); ok {
+		for code, msg := range registered.(map[int]string) {
+			merged[code] = msg
+		}
+	}
+	for code, msg := range messages {
+		merged[code] = msg
+	}
+	_messages.Store(lng, merged)
+}
+
+// LocalizedMessage returns the message of s in the language,
+// the original message is returned if no message registered for the language
+func (s *Status) LocalizedMessage(lng string) string {
+	if messages, ok := _messages.Load(lng); ok {
+		if msg, ok := messages.(map[int]string)[s.Code()]; ok {
+			return msg
+		}
+	}
+
+	return s.Message()
+}
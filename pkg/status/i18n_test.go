@@ -0,0 +1,36 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package status
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalizedMessage(t *testing.T) {
+	assert.Equal(t, "nothing found", NothingFound.LocalizedMessage("en"))
+	assert.Equal(t, NothingFound.Message(), NothingFound.LocalizedMessage("zh"))
+
+	biz := New(10002, "用户不存在")
+	assert.Equal(t, "用户不存在", biz.LocalizedMessage("en"))
+	RegisterMessages("en", map[int]string{biz.Code(): "user not found"})
+	assert.Equal(t, "user not found", biz.LocalizedMessage("en"))
+	assert.Equal(t, "nothing found", NothingFound.LocalizedMessage("en"))
+}
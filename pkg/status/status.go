@@ -130,6 +130,16 @@ func (s *Status) Proto() *spb.Status {
 	return proto.Clone(s.s).(*spb.Status)
 }
 
+// GRPCStatus returns grpc status of s. The code is carried in message with codes.Unknown,
+// the same as the way grpc handles non-status errors, so that details pass through
+func (s *Status) GRPCStatus() *gstatus.Status {
+	return gstatus.FromProto(&spb.Status{
+		Code:    int32(codes.Unknown),
+		Message: s.Error(),
+		Details: s.Proto().GetDetails(),
+	})
+}
+
 // WithDetails returns a new status with the provided details messages appended to the status.
 // If any errors are encountered, it returns nil and the first error encountered.
 func (s *Status) WithDetails(details ...proto.Message) (*Status, error) {
@@ -214,7 +224,13 @@ func ExtractStatus(err error) *Status {
 	case codes.Internal:
 		return ServerErr
 	case codes.Unknown:
-		return errToStatus(gst.Message())
+		estatus := errToStatus(gst.Message())
+		if details := gst.Proto().GetDetails(); len(details) > 0 && estatus != UndefinedErr {
+			p := estatus.Proto()
+			p.Details = details
+			return &Status{s: p}
+		}
+		return estatus
 	}
 
 	return ServerErr
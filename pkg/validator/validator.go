@@ -0,0 +1,161 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// Package validator validates requests of both http and rpc servers, violations are reported
+// as google.rpc.BadRequest field violations
+package validator
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/UnderTreeTech/waterdrop/pkg/utils/xstring"
+
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/zh"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	entrans "github.com/go-playground/validator/v10/translations/en"
+	zhtrans "github.com/go-playground/validator/v10/translations/zh"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+)
+
+var (
+	v   = validator.New()
+	uni = ut.New(en.New(), en.New(), zh.New())
+)
+
+func init() {
+	// report field by its json name, proto generated structs carry json tag
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "" || name == "-" {
+			return field.Name
+		}
+		return name
+	})
+
+	trans, _ := uni.GetTranslator("en")
+	if err := entrans.RegisterDefaultTranslations(v, trans); err != nil {
+		panic(err)
+	}
+	trans, _ = uni.GetTranslator("zh")
+	if err := zhtrans.RegisterDefaultTranslations(v, trans); err != nil {
+		panic(err)
+	}
+}
+
+// validatorAll is implemented by protoc-gen-validate generated messages, it reports all violations
+type validatorAll interface {
+	ValidateAll() error
+}
+
+// validatorOne is implemented by protoc-gen-validate generated messages, it reports the first violation
+type validatorOne interface {
+	Validate() error
+}
+
+// fieldError is implemented by protoc-gen-validate generated validation errors
+type fieldError interface {
+	Field() string
+	Reason() string
+	Cause() error
+}
+
+// multiError is implemented by protoc-gen-validate generated multi errors
+type multiError interface {
+	AllErrors() []error
+}
+
+// Validate validates the request and returns field violations. Messages generated by protoc-gen-validate
+// are validated by their own Validate methods, the others are validated by struct tags.
+// Descriptions of struct tag violations are localized by lng, such as `zh-CN` or `en`
+func Validate(req interface{}, lng string) []*errdetails.BadRequest_FieldViolation {
+	switch r := req.(type) {
+	case validatorAll:
+		return pgvViolations("", r.ValidateAll())
+	case validatorOne:
+		return pgvViolations("", r.Validate())
+	}
+
+	err := v.Struct(req)
+	if err == nil {
+		return nil
+	}
+
+	errs, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return []*errdetails.BadRequest_FieldViolation{{Description: err.Error()}}
+	}
+
+	trans, _ := uni.GetTranslator(xstring.GetLocaleLng(lng))
+	violations := make([]*errdetails.BadRequest_FieldViolation, 0, len(errs))
+	for _, fe := range errs {
+		// trim the top struct name of namespace
+		field := fe.Namespace()
+		if i := strings.Index(field, "."); i >= 0 {
+			field = field[i+1:]
+		}
+		violations = append(violations, &errdetails.BadRequest_FieldViolation{
+			Field:       field,
+			Description: fe.Translate(trans),
+		})
+	}
+	return violations
+}
+
+// pgvViolations converts protoc-gen-validate errors to field violations, embedded message errors are expanded
+func pgvViolations(prefix string, err error) []*errdetails.BadRequest_FieldViolation {
+	if err == nil {
+		return nil
+	}
+
+	if multi, ok := err.(multiError); ok {
+		violations := make([]*errdetails.BadRequest_FieldViolation, 0, len(multi.AllErrors()))
+		for _, e := range multi.AllErrors() {
+			violations = append(violations, pgvViolations(prefix, e)...)
+		}
+		return violations
+	}
+
+	fe, ok := err.(fieldError)
+	if !ok {
+		return []*errdetails.BadRequest_FieldViolation{{Field: prefix, Description: err.Error()}}
+	}
+
+	field := fe.Field()
+	if prefix != "" {
+		field = prefix + "." + field
+	}
+
+	cause := fe.Cause()
+	if _, ok := cause.(fieldError); ok {
+		return pgvViolations(field, cause)
+	}
+	if _, ok := cause.(multiError); ok {
+		return pgvViolations(field, cause)
+	}
+	return []*errdetails.BadRequest_FieldViolation{{Field: field, Description: fe.Reason()}}
+}
+
+// GetValidator returns the underlying validator engine which powers the
+// StructValidator implementation.
+func GetValidator() *validator.Validate {
+	return v
+}
@@ -0,0 +1,90 @@
+/*
+ *
+ * Copyright 2026 waterdrop authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package validator
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+)
+
+type mock struct {
+	Name  string `json:"name" validate:"required,min=6,max=10"`
+	Email string `validate:"required,email"`
+}
+
+// pgvMock mocks protoc-gen-validate generated message
+type pgvMock struct {
+	err error
+}
+
+func (m *pgvMock) Validate() error {
+	return m.err
+}
+
+// pgvError mocks protoc-gen-validate generated validation error
+type pgvError struct {
+	field  string
+	reason string
+	cause  error
+}
+
+func (e pgvError) Field() string  { return e.field }
+func (e pgvError) Reason() string { return e.reason }
+func (e pgvError) Cause() error   { return e.cause }
+func (e pgvError) Error() string  { return e.field + ": " + e.reason }
+
+// pgvMultiError mocks protoc-gen-validate generated multi error
+type pgvMultiError []error
+
+func (m pgvMultiError) Error() string      { return "multi error" }
+func (m pgvMultiError) AllErrors() []error { return m }
+
+// fields converts violations to field descriptions
+func fields(violations []*errdetails.BadRequest_FieldViolation) map[string]string {
+	m := make(map[string]string)
+	for _, v := range violations {
+		m[v.GetField()] = v.GetDescription()
+	}
+	return m
+}
+
+func TestValidate(t *testing.T) {
+	assert.Nil(t, Validate(&mock{Name: "waterdrop", Email: "example@example.com"}, ""))
+
+	req := &mock{Name: "water", Email: "waterdrop"}
+	assert.Equal(t, map[string]string{
+		"name":  "name must be at least 6 characters in length",
+		"Email": "Email must be a valid email address",
+	}, fields(Validate(req, "en")))
+	assert.Equal(t, "Email必须是一个有效的邮箱", fields(Validate(req, "zh-CN"))["Email"])
+
+	pgv := &pgvMock{err: pgvMultiError{
+		pgvError{field: "name", reason: "value length must be at least 6 runes"},
+		pgvError{field: "payload", reason: "embedded message failed validation", cause: pgvError{field: "body", reason: "value is required"}},
+	}}
+	assert.Equal(t, map[string]string{
+		"name":         "value length must be at least 6 runes",
+		"payload.body": "value is required",
+	}, fields(Validate(pgv, "")))
+	assert.Nil(t, Validate(&pgvMock{}, ""))
+	assert.Equal(t, map[string]string{"": "invalid"}, fields(Validate(&pgvMock{err: errors.New("invalid")}, "")))
+}